@@ -3,8 +3,10 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path"
+	"path/filepath"
+	"time"
 
+	"github.com/fermyon/verman-plugin/internal/verman"
 	"github.com/spf13/cobra"
 )
 
@@ -23,26 +25,57 @@ var aliasCmd = &cobra.Command{
 
 		alias, filePath := args[0], args[1]
 
-		aliasPath := path.Join(versionDir, alias)
+		aliasPath := filepath.Join(versionDir, alias)
+
+		lock, err := lockVersionDir()
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
 
 		if err := os.MkdirAll(aliasPath, 0755); err != nil {
 			return err
 		}
 
-		// In the case that there is already an existing alias + symlink,
-		// this deletes the symlink (or deletes nothing if the symlink doesn't exist) so a new one can be created
-		if err := os.Remove(path.Join(aliasPath, "spin")); err != nil {
-			if !os.IsNotExist(err) { // We don't care if we are deleting nothing, so this ignores any `os.IsNotExist` errors
-				return fmt.Errorf("failed to remove old symlink: %v", err)
-			}
+		// An existing alias is replaced
+		if err := binaryLinker.Link(filePath, filepath.Join(aliasPath, spinBinary)); err != nil {
+			return err
 		}
 
-		if err := os.Symlink(filePath, path.Join(aliasPath, "spin")); err != nil {
+		// The aliased binary is usually a local build that changes over time, so its size and digest aren't recorded
+		metadata := &verman.Metadata{Version: alias, Kind: verman.KindAlias, Source: filePath, InstalledAt: time.Now().UTC()}
+		if err := verman.WriteMetadata(aliasPath, metadata); err != nil {
 			return err
 		}
 
 		fmt.Printf("Created alias %q", alias)
 
+		// Without symlinks, the alias is a snapshot of the binary that is only refreshed by verman commands
+		if _, ok := binaryLinker.(verman.CopyLinker); ok {
+			fmt.Fprintf(os.Stderr, "\nWarning: the alias is a copy of %s, which is updated when the alias is set or run by verman. Run \"spin verman set %s\" again after rebuilding it to update the current version.\n", filePath, alias)
+		}
+
 		return nil
 	},
 }
+
+// refreshAlias re-links the binary of an alias whose target has been rebuilt since it was linked. Other versions are
+// left as they are.
+func refreshAlias(binaryDir string) error {
+	metadata, err := verman.ReadMetadata(binaryDir)
+	if err != nil || metadata == nil || metadata.Kind != verman.KindAlias {
+		return err
+	}
+
+	lock, err := lockVersionDir()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	if err := binaryLinker.Refresh(filepath.Join(binaryDir, spinBinary)); err != nil {
+		return fmt.Errorf("failed to update alias %q: %v", filepath.Base(binaryDir), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fermyon/verman-plugin/internal/verman"
+)
+
+func TestRefreshAlias(t *testing.T) {
+	dirs := useTestHome(t)
+	versionDir := dirs.VersionsDir()
+
+	// The Windows linker, whose aliases are snapshots of the binary
+	linker := binaryLinker
+	binaryLinker = verman.CopyLinker{}
+	t.Cleanup(func() { binaryLinker = linker })
+
+	target := filepath.Join(t.TempDir(), "spin")
+	if err := os.WriteFile(target, []byte("old build"), 0755); err != nil {
+		t.Fatalf("failed to write binary: %v", err)
+	}
+
+	aliasDir := filepath.Join(versionDir, "dev")
+	if err := os.MkdirAll(aliasDir, 0755); err != nil {
+		t.Fatalf("failed to create alias directory: %v", err)
+	}
+	if err := binaryLinker.Link(target, filepath.Join(aliasDir, spinBinary)); err != nil {
+		t.Fatalf("failed to link alias: %v", err)
+	}
+	if err := verman.WriteMetadata(aliasDir, &verman.Metadata{Version: "dev", Kind: verman.KindAlias, Source: target}); err != nil {
+		t.Fatalf("failed to write metadata: %v", err)
+	}
+
+	// A release isn't an alias, so it is never re-linked
+	releaseDir := filepath.Join(versionDir, "v2.7.0")
+	writeTestVersion(t, versionDir, "v2.7.0", "echo release")
+
+	rebuilt := target + ".new"
+	if err := os.WriteFile(rebuilt, []byte("new build"), 0755); err != nil {
+		t.Fatalf("failed to write binary: %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(rebuilt, later, later); err != nil {
+		t.Fatalf("failed to set modification time: %v", err)
+	}
+	if err := os.Rename(rebuilt, target); err != nil {
+		t.Fatalf("failed to replace binary: %v", err)
+	}
+
+	for _, dir := range []string{aliasDir, releaseDir} {
+		if err := refreshAlias(dir); err != nil {
+			t.Fatalf("failed to refresh %s: %v", filepath.Base(dir), err)
+		}
+	}
+
+	if content, err := os.ReadFile(filepath.Join(aliasDir, spinBinary)); err != nil || string(content) != "new build" {
+		t.Errorf("expected the alias to run the rebuilt binary, got: %q (error: %v)", content, err)
+	}
+
+	if content, err := os.ReadFile(filepath.Join(releaseDir, spinBinary)); err != nil || string(content) != "#!/bin/sh\necho release\n" {
+		t.Errorf("expected the release to be unchanged, got: %q (error: %v)", content, err)
+	}
+}
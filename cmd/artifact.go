@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/fermyon/verman-plugin/internal/verman"
+)
+
+// artifactName is the name of the GitHub Actions artifact to install for "pr/<number>" and "commit/<sha>", overriding
+// the artifact that is selected for the target platform
+var artifactName string
+
+// getArtifactSpin installs the CI build of a pull request or commit from the artifacts of its GitHub Actions workflow
+// runs. An installed pull request is replaced when new commits have been pushed to it. Progress is reported to the given
+// reporter, or to standard output if it is nil.
+func getArtifactSpin(versionDir string, ref verman.ArtifactRef, progress *progressReporter) error {
+	if progress == nil {
+		progress = newProgressReporter(os.Stdout)
+		defer progress.Close()
+	}
+
+	version := ref.VersionName()
+	repository := verman.NewRepositoryForOS(versionDir, targetOS)
+
+	installed, err := repository.Get(version)
+	if err != nil {
+		return err
+	}
+
+	if offline {
+		if installed == nil {
+			return fmt.Errorf("Spin %s can't be downloaded in offline mode", ref)
+		}
+
+		progress.Logf("Spin version %s found locally.\n", version)
+		return nil
+	}
+
+	if verman.GitHubToken() == "" {
+		return fmt.Errorf("downloading GitHub Actions artifacts requires a GitHub token: set $%s or $%s, or log in with \"gh auth login\"", verman.GitHubTokenEnvVar, verman.GitHubTokenFallbackEnvVar)
+	}
+
+	_, _, variant, err := spinPlatform()
+	if err != nil {
+		return err
+	}
+
+	source, err := getArtifactSource()
+	if err != nil {
+		return err
+	}
+
+	commit, err := source.ResolveCommit(ref)
+	if err != nil {
+		return err
+	}
+
+	if installed != nil && installed.Metadata.Commit == commit {
+		progress.Logf("Spin version %s (commit %s) found locally.\n", version, commit)
+		return nil
+	}
+
+	run, artifact, err := source.FindArtifact(commit, targetOS, targetArch, variant, artifactName)
+	if err != nil {
+		return err
+	}
+
+	progress.Logf("Downloading artifact %q of %s (%s)\n", artifact.Name, ref, run.HTMLURL)
+
+	cacheDir, err := getDownloadCacheDir()
+	if err != nil {
+		return err
+	}
+
+	artifactPath := filepath.Join(cacheDir, "artifacts", strconv.FormatInt(artifact.ID, 10)+".zip")
+	defer os.Remove(artifactPath)
+
+	digest, err := downloadFile(artifact.ArchiveDownloadURL, artifactPath, artifact.Name, progress)
+	if err != nil {
+		return err
+	}
+
+	// Only recently uploaded artifacts have a digest
+	if expected, ok := strings.CutPrefix(artifact.Digest, "sha256:"); ok && expected != digest {
+		return fmt.Errorf("checksum mismatch for artifact %q: expected %s, got %s", artifact.Name, expected, digest)
+	}
+
+	metadata := &verman.Metadata{
+		Version:       version,
+		Kind:          verman.KindArtifact,
+		Source:        run.HTMLURL,
+		ArchiveSHA256: digest,
+		Variant:       variant,
+		Commit:        commit,
+	}
+	if crossPlatform() {
+		metadata.Platform = targetOS + "/" + targetArch
+	}
+
+	err = installSpin(versionDir, metadata, true, func(tempDir, binaryName string) error {
+		return verman.ExtractArtifactBinary(artifactPath, tempDir, binaryName)
+	})
+	if err != nil {
+		return err
+	}
+
+	progress.Logf("Spin %s (commit %s) was installed as version %s\n", ref, commit, version)
+
+	return nil
+}
+
+// getArtifactSource returns the source of the GitHub Actions artifacts of Spin, which are taken from the GitHub
+// repository that releases are downloaded from
+func getArtifactSource() (*verman.ArtifactSource, error) {
+	config, err := getConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := getHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	repository := ""
+	if config.ReleaseSource.Type == "" || config.ReleaseSource.Type == verman.SourceGitHub {
+		repository = config.ReleaseSource.Repository
+	}
+
+	return verman.NewArtifactSource(client, repository), nil
+}
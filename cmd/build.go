@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/fermyon/verman-plugin/internal/verman"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// buildFeatures are the cargo features to build Spin with, overriding the configuration file
+	buildFeatures []string
+
+	// buildRepository is the git URL to build Spin from, overriding the configuration file
+	buildRepository string
+)
+
+var buildCmd = &cobra.Command{
+	Use:   "build <git-ref>",
+	Short: "Builds Spin from a branch, tag or commit into a managed version.",
+	Long:  "Builds Spin from a branch, tag or commit of its git repository with cargo, and installs the binary as a version named after the ref and commit, e.g. \"build-main-1a2b3c4\". The checkout is cached, so later builds only fetch new commits and rebuild what changed.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := getConfig()
+		if err != nil {
+			return err
+		}
+
+		buildConfig := config.Build
+		if cmd.Flags().Changed("features") {
+			buildConfig.Features = buildFeatures
+		}
+		if buildRepository != "" {
+			buildConfig.Repository = buildRepository
+		}
+		if buildConfig.Repository == "" {
+			buildConfig.Repository = verman.DefaultSpinGitURL
+		}
+
+		versionDir, err := getVersionDir()
+		if err != nil {
+			return err
+		}
+
+		return buildSpin(versionDir, args[0], buildConfig)
+	},
+}
+
+// buildSpin builds ref from the cached checkout of the Spin repository and installs the result in versionDir, unless
+// a build of the same ref, commit and features is already installed
+func buildSpin(versionDir, ref string, buildConfig verman.BuildConfig) error {
+	// cargo builds for this machine, so a binary for another platform can't be built
+	if crossPlatform() {
+		return fmt.Errorf("Spin can only be built for this machine (%s/%s), not for %s/%s", runtime.GOOS, runtime.GOARCH, targetOS, targetArch)
+	}
+
+	dirs, err := getDirs()
+	if err != nil {
+		return err
+	}
+
+	for _, tool := range []string{"git", "cargo"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			return fmt.Errorf("building Spin requires %s, which wasn't found in $PATH", tool)
+		}
+	}
+
+	if err := os.MkdirAll(dirs.State, 0755); err != nil {
+		return err
+	}
+
+	// Builds share a single checkout, so only one can run at a time
+	lock, err := acquireLock(filepath.Join(dirs.State, "build.lock"))
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	checkout := &verman.SourceCheckout{Dir: filepath.Join(dirs.Cache, "source", "spin"), URL: buildConfig.Repository, Output: os.Stderr}
+
+	fmt.Printf("Checking out %s from %s\n", ref, buildConfig.Repository)
+
+	commit, err := checkout.Checkout(ref, offline)
+	if err != nil {
+		return err
+	}
+
+	version := verman.BuildVersionName(ref, commit, buildConfig.Features)
+
+	installed, err := verman.NewRepository(versionDir).IsInstalled(version)
+	if err != nil {
+		return err
+	}
+
+	if installed {
+		fmt.Printf("Spin version %s is already installed. Run \"spin verman set %s\" to use it.\n", version, version)
+		return nil
+	}
+
+	cargoArgs := verman.CargoBuildArgs(buildConfig.Features)
+	fmt.Printf("Building commit %s: cargo %s\n", commit, strings.Join(cargoArgs, " "))
+
+	cargo := exec.Command("cargo", cargoArgs...)
+	cargo.Dir = checkout.Dir
+	cargo.Stdout = os.Stdout
+	cargo.Stderr = os.Stderr
+	if err := cargo.Run(); err != nil {
+		return fmt.Errorf("failed to build Spin: %v", err)
+	}
+
+	metadata := &verman.Metadata{
+		Version:  version,
+		Kind:     verman.KindBuild,
+		Source:   buildConfig.Repository,
+		Commit:   commit,
+		Features: buildConfig.Features,
+	}
+
+	err = installSpin(versionDir, metadata, false, func(tempDir, binaryName string) error {
+		return verman.CopyFile(verman.BuiltBinaryPath(checkout.Dir, runtime.GOOS), filepath.Join(tempDir, binaryName))
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Spin version %s was built and installed. Run \"spin verman set %s\" to use it.\n", version, version)
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fermyon/verman-plugin/internal/verman"
+)
+
+func TestBuildSpinRejectsOtherPlatforms(t *testing.T) {
+	dirs := useTestHome(t)
+
+	previousOS, previousArch := targetOS, targetArch
+	t.Cleanup(func() { targetOS, targetArch = previousOS, previousArch })
+
+	targetOS, targetArch = "plan9", "riscv64"
+
+	err := buildSpin(dirs.VersionsDir(), "main", verman.BuildConfig{Repository: verman.DefaultSpinGitURL})
+	if err == nil || !strings.Contains(err.Error(), "can only be built for this machine") {
+		t.Errorf("expected a build for another platform to be rejected, got: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fermyon/verman-plugin/internal/verman"
+)
+
+var (
+	// vermanHome is the value of the --home flag
+	vermanHome string
+
+	dirsOnce     sync.Once
+	resolvedDirs verman.Dirs
+	dirsErr      error
+)
+
+// getDirs returns the directories verman stores its files in, migrating a legacy ~/.spin_verman directory the first time
+// the XDG directories are used. The shim never migrates, as it runs on every "spin" invocation; it keeps using the
+// legacy directory until verman itself has moved it.
+func getDirs() (verman.Dirs, error) {
+	dirsOnce.Do(func() {
+		resolvedDirs, dirsErr = verman.ResolveDirs(vermanHome)
+		if dirsErr != nil || vermanHome != "" || os.Getenv(verman.HomeEnvVar) != "" {
+			return
+		}
+
+		resolvedDirs, dirsErr = migrateLegacyHome(resolvedDirs, !isShimInvocation())
+	})
+
+	return resolvedDirs, dirsErr
+}
+
+// migrateLegacyHome moves the versions in ~/.spin_verman into the XDG data directory, holding the versions lock so that
+// concurrent invocations don't race on the move. If they can't be moved (or migrate isn't set), the legacy directory
+// keeps being used so that the installed versions remain available.
+func migrateLegacyHome(dirs verman.Dirs, migrate bool) (verman.Dirs, error) {
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return verman.Dirs{}, err
+	}
+
+	legacyHome := filepath.Join(userHome, verman.LegacyHomeDirName)
+
+	pending, err := verman.LegacyHomePending(legacyHome, dirs)
+	if err != nil || !pending {
+		return dirs, err
+	}
+
+	if !migrate {
+		return verman.HomeDirs(legacyHome), nil
+	}
+
+	// lockVersionDir can't be used, as it resolves the directories itself
+	if err := os.MkdirAll(dirs.State, 0755); err != nil {
+		return verman.Dirs{}, err
+	}
+
+	lock, err := acquireLock(versionDirLockPath(dirs))
+	if err != nil {
+		return verman.Dirs{}, err
+	}
+	defer lock.Release()
+
+	// Another process may have migrated the legacy directory while waiting for the lock, in which case there is
+	// nothing left to do
+	migrated, err := verman.MigrateLegacyHome(legacyHome, dirs)
+	if err != nil {
+		if migrated {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			return dirs, nil
+		}
+
+		fmt.Fprintf(os.Stderr, "Warning: continuing to use %s as the installed versions couldn't be moved: %v\n", legacyHome, err)
+		return verman.HomeDirs(legacyHome), nil
+	}
+
+	if migrated {
+		fmt.Fprintf(os.Stderr, "Moved the installed Spin versions from %s to %s (%s now links to the new location)\n", legacyHome, dirs.Data, legacyHome)
+	}
+
+	return dirs, nil
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/fermyon/verman-plugin/internal/verman"
+)
+
+const (
+	partialSuffix         = ".part"
+	partialMetadataSuffix = ".part.json"
+)
+
+// keepArchives indicates whether downloaded archives are retained in the download cache after they are installed
+var keepArchives bool
+
+// partialDownload records the validators of a partially downloaded file, so that resuming it can be refused if the
+// file on the server has changed in the meantime
+type partialDownload struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// getDownloadCacheDir returns the directory in which downloaded archives (complete or partial) are stored
+func getDownloadCacheDir() (string, error) {
+	dirs, err := getDirs()
+	if err != nil {
+		return "", err
+	}
+
+	cacheDir := filepath.Join(dirs.Cache, "downloads")
+
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", err
+	}
+
+	return cacheDir, nil
+}
+
+// downloadFile downloads url to destPath, returning the hex-encoded SHA-256 digest of the complete file.
+// The download is written to destPath + ".part" until it completes, and a partial file left by an interrupted download is
+// resumed with a Range request as long as the server confirms (through ETag or Last-Modified) that the file hasn't changed.
+func downloadFile(url, destPath, name string, progress *progressReporter) (string, error) {
+	partPath := destPath + partialSuffix
+	metadataPath := destPath + partialMetadataSuffix
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	offset := resumeOffset(partPath, metadataPath, url)
+	if offset > 0 {
+		metadata, _ := readPartialDownload(metadataPath)
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+
+		// If-Range makes the server send the whole file instead of a range if it has changed
+		if metadata.ETag != "" && !strings.HasPrefix(metadata.ETag, "W/") {
+			req.Header.Set("If-Range", metadata.ETag)
+		} else {
+			req.Header.Set("If-Range", metadata.LastModified)
+		}
+	}
+
+	client, err := getHTTPClient()
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if !rangeStartsAt(resp.Header.Get("Content-Range"), offset) {
+			return "", fmt.Errorf("unexpected Content-Range %q when resuming %s", resp.Header.Get("Content-Range"), name)
+		}
+	case http.StatusOK:
+		// The server ignored the range or the file has changed, so the download starts from the beginning
+		offset = 0
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial file is no longer consistent with the server, so it is discarded for the next attempt
+		os.Remove(partPath)
+		os.Remove(metadataPath)
+		return "", fmt.Errorf("the partial download of %s could not be resumed; please try again", name)
+	default:
+		return "", verman.StatusError(resp, fmt.Sprintf("unable to download %s", name))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return "", err
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_WRONLY | os.O_APPEND
+	}
+
+	out, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+
+	// The file is hashed while it is written so it doesn't need to be read back from disk, apart from any resumed prefix
+	hash := sha256.New()
+	if offset > 0 {
+		if err := hashPrefix(partPath, offset, hash); err != nil {
+			return "", err
+		}
+	}
+
+	if err := writePartialDownload(metadataPath, partialDownload{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}); err != nil {
+		return "", err
+	}
+
+	total := resp.ContentLength
+	if total >= 0 {
+		total += offset
+	}
+
+	bar := progress.Start(name, total)
+	bar.Resume(offset)
+
+	_, err = io.Copy(io.MultiWriter(out, hash, bar), resp.Body)
+	bar.Finish(err)
+	if err != nil {
+		// The partial file is kept so the next attempt can resume it
+		return "", err
+	}
+
+	if err := out.Close(); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(partPath, destPath); err != nil {
+		return "", err
+	}
+
+	os.Remove(metadataPath)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// resumeOffset returns the size of a partial download of url that can be resumed, or 0 if it must start from the beginning
+func resumeOffset(partPath, metadataPath, url string) int64 {
+	info, err := os.Stat(partPath)
+	if err != nil || info.Size() == 0 {
+		return 0
+	}
+
+	metadata, err := readPartialDownload(metadataPath)
+	if err != nil || metadata.URL != url || (metadata.ETag == "" && metadata.LastModified == "") {
+		return 0
+	}
+
+	return info.Size()
+}
+
+// rangeStartsAt checks that a "Content-Range: bytes <start>-<end>/<size>" header starts at the expected offset
+func rangeStartsAt(contentRange string, offset int64) bool {
+	byteRange, found := strings.CutPrefix(contentRange, "bytes ")
+	if !found {
+		return false
+	}
+
+	start, _, found := strings.Cut(byteRange, "-")
+	if !found {
+		return false
+	}
+
+	n, err := strconv.ParseInt(start, 10, 64)
+	return err == nil && n == offset
+}
+
+func hashPrefix(filePath string, n int64, w io.Writer) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.CopyN(w, f, n)
+	return err
+}
+
+func readPartialDownload(metadataPath string) (partialDownload, error) {
+	var metadata partialDownload
+
+	content, err := os.ReadFile(metadataPath)
+	if err != nil {
+		return metadata, err
+	}
+
+	err = json.Unmarshal(content, &metadata)
+	return metadata, err
+}
+
+func writePartialDownload(metadataPath string, metadata partialDownload) error {
+	content, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(metadataPath, content, 0644)
+}
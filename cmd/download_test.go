@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fermyon/verman-plugin/internal/verman"
+)
+
+// discardProgress returns a progress reporter whose output is discarded
+func discardProgress(t *testing.T) *progressReporter {
+	t.Helper()
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", os.DevNull, err)
+	}
+	t.Cleanup(func() { devNull.Close() })
+
+	progress := newProgressReporter(devNull)
+	t.Cleanup(progress.Close)
+
+	return progress
+}
+
+func sha256Hex(content string) string {
+	digest := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(digest[:])
+}
+
+func TestDownloadFileResume(t *testing.T) {
+	modified := time.Date(2024, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		handler  func(w http.ResponseWriter, r *http.Request)
+		expected string
+	}{
+		{
+			name: "Range is resumed",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Range") != "bytes=6-" || r.Header.Get("If-Range") != `"v1"` {
+					t.Errorf("expected a range request validated by the ETag, got Range: %q, If-Range: %q", r.Header.Get("Range"), r.Header.Get("If-Range"))
+				}
+				w.Header().Set("ETag", `"v1"`)
+				http.ServeContent(w, r, "spin.tar.gz", modified, strings.NewReader("hello world"))
+			},
+			expected: "hello world",
+		},
+		{
+			name: "Range is ignored",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", `"v1"`)
+				fmt.Fprint(w, "hello world")
+			},
+			expected: "hello world",
+		},
+		{
+			name: "File has changed",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", `"v2"`)
+				http.ServeContent(w, r, "spin.tar.gz", modified, strings.NewReader("new content"))
+			},
+			expected: "new content",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dirs := useTestHome(t)
+
+			server := httptest.NewServer(http.HandlerFunc(tt.handler))
+			defer server.Close()
+
+			url := server.URL + "/spin.tar.gz"
+			destPath := filepath.Join(dirs.Cache, "downloads", "v2.7.0", "spin.tar.gz")
+
+			// An earlier attempt was interrupted after the first 6 bytes
+			if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+				t.Fatalf("failed to create cache directory: %v", err)
+			}
+			if err := os.WriteFile(destPath+partialSuffix, []byte("hello "), 0644); err != nil {
+				t.Fatalf("failed to write partial download: %v", err)
+			}
+			if err := writePartialDownload(destPath+partialMetadataSuffix, partialDownload{URL: url, ETag: `"v1"`}); err != nil {
+				t.Fatalf("failed to write partial download metadata: %v", err)
+			}
+
+			digest, err := downloadFile(url, destPath, "spin.tar.gz", discardProgress(t))
+			if err != nil {
+				t.Fatalf("failed to download: %v", err)
+			}
+
+			content, err := os.ReadFile(destPath)
+			if err != nil {
+				t.Fatalf("failed to read download: %v", err)
+			}
+
+			if string(content) != tt.expected {
+				t.Errorf("expected content: %q, got: %q", tt.expected, content)
+			}
+			if digest != sha256Hex(tt.expected) {
+				t.Errorf("expected the digest of the complete file, got: %s", digest)
+			}
+
+			for _, path := range []string{destPath + partialSuffix, destPath + partialMetadataSuffix} {
+				if _, err := os.Stat(path); !os.IsNotExist(err) {
+					t.Errorf("expected %s to be removed, got: %v", filepath.Base(path), err)
+				}
+			}
+		})
+	}
+}
+
+func TestDownloadFileUnsatisfiableRange(t *testing.T) {
+	useTestHome(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+	}))
+	defer server.Close()
+
+	url := server.URL + "/spin.tar.gz"
+	destPath := filepath.Join(t.TempDir(), "spin.tar.gz")
+
+	if err := os.WriteFile(destPath+partialSuffix, []byte("hello "), 0644); err != nil {
+		t.Fatalf("failed to write partial download: %v", err)
+	}
+	if err := writePartialDownload(destPath+partialMetadataSuffix, partialDownload{URL: url, ETag: `"v1"`}); err != nil {
+		t.Fatalf("failed to write partial download metadata: %v", err)
+	}
+
+	if _, err := downloadFile(url, destPath, "spin.tar.gz", discardProgress(t)); err == nil {
+		t.Fatalf("expected the download to fail")
+	}
+
+	// The next attempt starts from the beginning
+	if _, err := os.Stat(destPath + partialSuffix); !os.IsNotExist(err) {
+		t.Errorf("expected the partial download to be discarded, got: %v", err)
+	}
+}
+
+func TestDownloadSpinKeepsOtherArchives(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test release is a tar.gz archive")
+	}
+
+	dirs := useTestHome(t)
+
+	spinOS, spinArch, _, err := spinPlatform()
+	if err != nil {
+		t.Fatalf("failed to determine the platform: %v", err)
+	}
+
+	// A local mirror with a release for this platform
+	mirror := t.TempDir()
+	assetName := verman.ReleaseAssetName("v2.7.0", spinOS, spinArch)
+	archive := writeTestArchive(t, filepath.Join(mirror, "v2.7.0", assetName), "#!/bin/sh\necho spin 2.7.0\n")
+	checksums := fmt.Sprintf("%s  %s\n", archive, assetName)
+	if err := os.WriteFile(filepath.Join(mirror, "v2.7.0", verman.ChecksumsFileName("v2.7.0")), []byte(checksums), 0644); err != nil {
+		t.Fatalf("failed to write checksums: %v", err)
+	}
+	t.Setenv(verman.MirrorEnvVar, mirror)
+
+	// A download of the same version for another platform is in progress
+	otherPart := filepath.Join(dirs.Cache, "downloads", "v2.7.0", "spin-v2.7.0-other-platform.tar.gz"+partialSuffix)
+	if err := os.MkdirAll(filepath.Dir(otherPart), 0755); err != nil {
+		t.Fatalf("failed to create cache directory: %v", err)
+	}
+	if err := os.WriteFile(otherPart, []byte("partial"), 0644); err != nil {
+		t.Fatalf("failed to write partial download: %v", err)
+	}
+
+	if err := downloadSpin(dirs.VersionsDir(), "v2.7.0", discardProgress(t)); err != nil {
+		t.Fatalf("failed to download Spin: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dirs.VersionsDir(), "v2.7.0", spinBinary)); err != nil {
+		t.Errorf("expected Spin to be installed: %v", err)
+	}
+
+	if _, err := os.Stat(otherPart); err != nil {
+		t.Errorf("expected the other platform's partial download to be kept: %v", err)
+	}
+
+	archivePath := filepath.Join(dirs.Cache, "downloads", "v2.7.0", assetName)
+	for _, path := range []string{archivePath, archivePath + ".sha256"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed after installing, got: %v", filepath.Base(path), err)
+		}
+	}
+}
+
+// writeTestArchive writes a release archive containing a Spin binary with the given content, returning its digest
+func writeTestArchive(t *testing.T, archivePath, binary string) string {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(archivePath), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: "spin", Mode: 0755, Size: int64(len(binary))}); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+	if _, err := tw.Write([]byte(binary)); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+
+	content, err := os.ReadFile(archivePath)
+	if err != nil {
+		t.Fatalf("failed to read archive: %v", err)
+	}
+
+	return sha256Hex(string(content))
+}
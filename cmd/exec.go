@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var execCmd = &cobra.Command{
+	Use:     "exec [version] -- [args]",
+	Aliases: []string{"run"},
+	Short:   "Runs Spin with the requested version without changing the current version.",
+	Long:    "Runs Spin with the requested version without changing the current version. If the requested version is not found locally, it will be downloaded. The exit code, stdio and signals of the Spin process are passed through: \"spin verman exec 2.5.0 -- build --up\". verman's own messages are written to stderr, so stdout only carries the output of Spin.",
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		versionDir, err := getVersionDir()
+		if err != nil {
+			return err
+		}
+
+		version, spinArgs, err := execTarget(versionDir, args, os.Stderr)
+		if err != nil {
+			return err
+		}
+
+		progress := newProgressReporter(os.Stderr)
+		err = downloadSpin(versionDir, version, progress)
+		progress.Close()
+		if err != nil {
+			return err
+		}
+
+		binaryDir := filepath.Join(versionDir, version)
+
+		if err := verifyInstalledBinary(binaryDir); err != nil {
+			return err
+		}
+
+		if err := refreshAlias(binaryDir); err != nil {
+			return err
+		}
+
+		if err := execSpin(filepath.Join(binaryDir, spinBinary), spinArgs); err != nil {
+			return fmt.Errorf("failed to run Spin version %s: %v", version, err)
+		}
+
+		return nil
+	},
+}
+
+// execTarget resolves the version requested by exec's arguments, returning it along with the arguments for Spin. How
+// the version was resolved is reported to out.
+func execTarget(versionDir string, args []string, out io.Writer) (string, []string, error) {
+	version, err := resolveVersion(versionDir, args[0], out)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Flag parsing stops at the version, so a "--" separator is passed through with the Spin arguments
+	spinArgs := args[1:]
+	if len(spinArgs) > 0 && spinArgs[0] == "--" {
+		spinArgs = spinArgs[1:]
+	}
+
+	return version, spinArgs, nil
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// execTestHomeEnvVar makes the test binary run "exec" against the given home directory, as a child process of
+// TestExecCommand, since running Spin replaces the process
+const execTestHomeEnvVar = "VERMAN_TEST_EXEC_HOME"
+
+func TestExecTarget(t *testing.T) {
+	dirs := useTestHome(t)
+	versionDir := dirs.VersionsDir()
+
+	previousOffline := offline
+	offline = true
+	t.Cleanup(func() { offline = previousOffline })
+
+	for _, version := range []string{"v2.4.0", "v2.5.0", "dev"} {
+		writeTestVersion(t, versionDir, version, "exit 0")
+	}
+
+	tests := []struct {
+		name             string
+		args             []string
+		expectedVersion  string
+		expectedSpinArgs []string
+	}{
+		{name: "Exact version", args: []string{"2.5.0", "--", "--version"}, expectedVersion: "v2.5.0", expectedSpinArgs: []string{"--version"}},
+		{name: "Constraint", args: []string{"2.4", "build", "--up"}, expectedVersion: "v2.4.0", expectedSpinArgs: []string{"build", "--up"}},
+		{name: "Alias", args: []string{"dev"}, expectedVersion: "dev", expectedSpinArgs: []string{}},
+		// Only the separator after the version is removed, anything after it belongs to Spin
+		{name: "Repeated separator", args: []string{"v2.5.0", "--", "--", "up"}, expectedVersion: "v2.5.0", expectedSpinArgs: []string{"--", "up"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			version, spinArgs, err := execTarget(versionDir, tt.args, &out)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if version != tt.expectedVersion {
+				t.Errorf("expected version: %s, got: %s", tt.expectedVersion, version)
+			}
+			if strings.Join(spinArgs, " ") != strings.Join(tt.expectedSpinArgs, " ") || len(spinArgs) != len(tt.expectedSpinArgs) {
+				t.Errorf("expected Spin arguments: %q, got: %q", tt.expectedSpinArgs, spinArgs)
+			}
+		})
+	}
+}
+
+func TestExecCommand(t *testing.T) {
+	if home := os.Getenv(execTestHomeEnvVar); home != "" {
+		rootCmd.SetArgs([]string{"--home", home, "--offline", "exec", "2.5", "--", "--version"})
+		if err := rootCmd.Execute(); err != nil {
+			os.Exit(100)
+		}
+		os.Exit(101)
+	}
+
+	if runtime.GOOS == "windows" {
+		t.Skip("the test versions of Spin are shell scripts")
+	}
+
+	home := t.TempDir()
+	writeTestVersion(t, filepath.Join(home, "versions"), "v2.5.0", `echo "spin $*"; exit 3`)
+
+	child := exec.Command(os.Args[0], "-test.run=^TestExecCommand$")
+	child.Env = append(os.Environ(), execTestHomeEnvVar+"="+home)
+
+	var stdout, stderr bytes.Buffer
+	child.Stdout = &stdout
+	child.Stderr = &stderr
+
+	err := child.Run()
+
+	// The exit code of Spin is passed through
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Fatalf("expected exit code 3, got: %v (stderr: %s)", err, stderr.String())
+	}
+
+	// Only Spin writes to stdout, so its output can be captured
+	if stdout.String() != "spin --version\n" {
+		t.Errorf("expected only the output of Spin on stdout, got: %q", stdout.String())
+	}
+
+	if !strings.Contains(stderr.String(), `Resolved "2.5" to Spin version v2.5.0`) {
+		t.Errorf("expected verman's messages on stderr, got: %q", stderr.String())
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+)
+
+// execSpin replaces the current process with the given Spin binary, so that its stdio, signals and exit code
+// are those of the Spin process itself. It only returns if the binary could not be executed.
+func execSpin(binaryPath string, args []string) error {
+	return syscall.Exec(binaryPath, append([]string{binaryPath}, args...), os.Environ())
+}
@@ -0,0 +1,39 @@
+//go:build windows
+
+package cmd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+)
+
+// execSpin runs the given Spin binary as a child process, forwarding stdio and interrupts, and exits with its exit code.
+// Windows has no equivalent of exec(2), so this only returns if the binary could not be started.
+func execSpin(binaryPath string, args []string) error {
+	spinCmd := exec.Command(binaryPath, args...)
+	spinCmd.Stdin = os.Stdin
+	spinCmd.Stdout = os.Stdout
+	spinCmd.Stderr = os.Stderr
+
+	// The console delivers Ctrl+C to the whole process group, so the child receives it directly; ignoring it here
+	// stops this process from exiting before the child has finished handling it
+	signal.Ignore(os.Interrupt)
+
+	if err := spinCmd.Start(); err != nil {
+		return err
+	}
+
+	if err := spinCmd.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+
+		return err
+	}
+
+	os.Exit(0)
+	return nil
+}
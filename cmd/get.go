@@ -1,44 +1,116 @@
 package cmd
 
 import (
-	"archive/tar"
-	"bytes"
-	"compress/gzip"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"path"
+	"path/filepath"
 	"runtime"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/fermyon/verman-plugin/internal/verman"
 	"github.com/spf13/cobra"
 	"golang.org/x/mod/semver"
 )
 
+const (
+	// staleInstallAge is how old an install directory must be before it is assumed to have been left by a crashed install
+	staleInstallAge = time.Hour
+)
+
+// verifySignature indicates whether the cosign signature of downloaded Spin binaries should be verified before installing them
+var verifySignature bool
+
+// spinVariant is the build variant of Spin to download on Linux: auto, glibc or static
+var spinVariant string
+
+// spinBinary is the file name of the Spin binary, and binaryLinker links "current_version" and aliases to binaries
+var (
+	spinBinary   = verman.BinaryName(runtime.GOOS)
+	binaryLinker = verman.NewLinker(runtime.GOOS)
+)
+
+// targetOS and targetArch are the Go OS and architecture that "get" downloads Spin for, which default to this machine's
+var targetOS, targetArch string
+
+// exportPath is where "get" writes the downloaded binary to instead of installing it
+var exportPath string
+
+// downloadJobs is the maximum number of versions "get" downloads at the same time
+var downloadJobs int
+
 var getCmd = &cobra.Command{
 	Use:   "get",
 	Short: "Downloads the binary for the requested version if not found locally.",
-	Long:  "Downloads the binary for the requested version if not found locally. Multiple versions can be downloaded at once: \"spin verman get 2.1.0 canary\".",
+	Long:  "Downloads the binary for the requested version if not found locally. Multiple versions can be downloaded at once: \"spin verman get 2.1.0 canary\". The CI builds of pull requests and commits are installed from GitHub Actions artifacts with \"pr/<number>\" and \"commit/<sha>\", which requires a GitHub token. Versions can also be constraints such as \"2.7\", \"~2.6\" or \">=2.5 <3\", which resolve to the highest matching release. Spin has no long-term support releases, so \"lts\" (like \"stable\", \"*\" and \"x\") selects the latest stable release.",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		versions, err := verman.GetDesiredVersionsForGet(args)
 		if err != nil {
 			return err
 		}
 
-		versionDir, err := getVersionDir()
+		versionDir, err := getTargetVersionDir()
 		if err != nil {
 			return err
 		}
 
+		if len(args) == 0 {
+			if err := printVersionSource(); err != nil {
+				return err
+			}
+		}
+
+		var resolved []string
+		var artifacts []verman.ArtifactRef
+		seen := map[string]bool{}
 		for _, version := range versions {
-			if err := downloadSpin(versionDir, version); err != nil {
+			// CI builds of pull requests and commits are installed from GitHub Actions artifacts rather than releases
+			if ref, ok, err := verman.ParseArtifactRef(version); ok {
+				if err != nil {
+					return err
+				}
+				artifacts = append(artifacts, ref)
+				continue
+			}
+
+			version, err := resolveVersion(versionDir, version, os.Stdout)
+			if err != nil {
+				return err
+			}
+
+			// Concurrent downloads of the same version would race on the same files
+			if !seen[version] {
+				seen[version] = true
+				resolved = append(resolved, version)
+			}
+		}
+
+		if exportPath != "" {
+			if len(artifacts) > 0 {
+				return fmt.Errorf("--export doesn't support %s; install it and export the installed version instead", artifacts[0])
+			}
+
+			if len(resolved) != 1 {
+				return fmt.Errorf("--export requires a single version, got %d", len(resolved))
+			}
+
+			return exportSpin(versionDir, resolved[0], exportPath)
+		}
+
+		for _, ref := range artifacts {
+			if err := getArtifactSpin(versionDir, ref, nil); err != nil {
 				return err
 			}
 		}
 
-		return nil
+		if len(resolved) == 0 {
+			return nil
+		}
+
+		return downloadSpinVersions(versionDir, resolved, downloadJobs)
 	},
 }
 
@@ -46,17 +118,21 @@ var getLatestStableCmd = &cobra.Command{
 	Use:   "latest",
 	Short: "Downloads the binary for the latest stable version if not found locally.",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		versionDir, err := getVersionDir()
+		versionDir, err := getTargetVersionDir()
 		if err != nil {
 			return err
 		}
 
-		version, err := getLatestTag()
+		version, err := getLatestTag(versionDir)
 		if err != nil {
 			return err
 		}
 
-		if err := downloadSpin(versionDir, version); err != nil {
+		if exportPath != "" {
+			return exportSpin(versionDir, version, exportPath)
+		}
+
+		if err := downloadSpin(versionDir, version, nil); err != nil {
 			return err
 		}
 
@@ -82,12 +158,12 @@ func exists(path string) (bool, error) {
 
 // getVersionDir returns the directory in which the "spin verman" version files will be stored
 func getVersionDir() (string, error) {
-	homeDir, err := os.UserHomeDir()
+	dirs, err := getDirs()
 	if err != nil {
 		return "", err
 	}
 
-	versionDir := path.Join(homeDir, ".spin_verman", "versions")
+	versionDir := dirs.VersionsDir()
 
 	dirExists, err := exists(versionDir)
 	if err != nil {
@@ -103,84 +179,223 @@ func getVersionDir() (string, error) {
 	return versionDir, nil
 }
 
-// getLatestTag returns a string containing the tag of the latest stable version of Spin
-func getLatestTag() (string, error) {
-	var latestRelease struct {
-		TagName string `json:"tag_name"`
-	}
-
-	resp, err := http.Get("https://api.github.com/repos/fermyon/spin/releases/latest")
+// getLatestTag returns a string containing the tag of the latest stable version of Spin. In offline mode without a
+// cached release index, the highest stable release installed in versionDir is used instead.
+func getLatestTag(versionDir string) (string, error) {
+	index, err := getReleaseIndex(false)
 	if err != nil {
+		if offline {
+			if latest, installedErr := latestInstalledRelease(versionDir); installedErr == nil {
+				fmt.Printf("No release index has been cached; using the latest installed stable version of Spin, %s\n", latest)
+				return latest, nil
+			}
+		}
+
 		return "", fmt.Errorf("unable to retrieve the tag for the latest stable version of Spin: %v", err)
 	}
 
-	jsonBytes, err := io.ReadAll(resp.Body)
+	return index.LatestStable()
+}
+
+// latestInstalledRelease returns the highest stable release of Spin installed in versionDir
+func latestInstalledRelease(versionDir string) (string, error) {
+	versions, err := verman.NewRepositoryForOS(versionDir, targetOS).List()
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
 
-	if err := json.Unmarshal(jsonBytes, &latestRelease); err != nil {
-		return "", err
+	var releases []string
+	for _, version := range versions {
+		if version.Metadata.Kind == verman.KindRelease {
+			releases = append(releases, version.Name)
+		}
 	}
 
-	return latestRelease.TagName, nil
+	return verman.ResolveVersion("stable", releases)
 }
 
-// downloadSpin will retrieve the desired version of Spin if it is not present in the version directory
-func downloadSpin(versionDir, version string) error {
-	var spinArch string
-	var spinOS string
-
-	// Checking for compatible architectures
-	if runtime.GOARCH == "amd64" {
-		spinArch = "amd64"
-	} else if runtime.GOARCH == "arm64" {
-		spinArch = "aarch64"
-	} else {
-		return fmt.Errorf("%q is not an architecture that Spin supports", runtime.GOARCH)
+// resolveVersion turns a requested version into the name of the version to install. Version constraints (e.g. "2.7", "^2.5" or
+// ">=2.5 <3") are resolved to the highest matching remote release, or the highest matching installed version when the remote
+// releases cannot be loaded. Exact versions are normalized to include the "v" prefix, and aliases are returned unchanged.
+// "canary@previous" is resolved to the most recent previous canary build. How a constraint was resolved is reported to out.
+func resolveVersion(versionDir, spec string, out io.Writer) (string, error) {
+	if spec == "canary" {
+		return spec, nil
 	}
 
-	// Checking for compatible operating systems
-	if runtime.GOOS == "linux" {
-		// TODO: When would we want to download 'static-linux' vs just 'linux'?
-		spinOS = "linux"
-	} else if runtime.GOOS == "darwin" {
-		spinOS = "macos"
-	} else {
-		return fmt.Errorf("%q is not an OS that this Spin plugin supports", runtime.GOOS)
+	if spec == verman.CanaryPrevious {
+		return verman.NewRepository(versionDir).PreviousCanary()
 	}
 
-	dirExists, err := exists(versionDir)
+	// Installed versions and aliases always take precedence
+	installed, err := exists(filepath.Join(versionDir, spec))
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	// Determines if we need to pull the file from GitHub
-	var versionFolderExists bool
+	if installed {
+		return spec, nil
+	}
 
-	if !dirExists {
-		if err = os.MkdirAll(versionDir, 0755); err != nil {
-			return err
+	if !verman.IsVersionConstraint(spec) {
+		if !semver.IsValid(spec) && semver.IsValid("v"+spec) {
+			return "v" + spec, nil
+		}
+
+		return spec, nil
+	}
+
+	var candidates []string
+
+	releases, err := loadSpinReleases()
+	if err == nil {
+		for _, release := range releases {
+			candidates = append(candidates, release.TagName)
 		}
 	} else {
-		dirFiles, err := os.ReadDir(versionDir)
+		fmt.Fprintf(out, "Unable to load remote Spin releases (%v); resolving %q against locally installed versions\n", err, spec)
+
+		candidates, err = verman.NewRepository(versionDir).Names()
 		if err != nil {
-			return err
+			return "", err
+		}
+	}
+
+	version, err := verman.ResolveVersion(spec, candidates)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(out, "Resolved %q to Spin version %s\n", spec, version)
+
+	return version, nil
+}
+
+// printVersionSource reports which file the project's Spin version came from, along with any plugins and templates
+// required by its ".spin-version" file
+func printVersionSource() error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	source, err := verman.FindVersionSource(cwd)
+	if err != nil || source == nil {
+		return err
+	}
+
+	fmt.Printf("Using Spin version %s from %s\n", source.Version, source.Path)
+
+	if source.SpinVersionFile == nil {
+		return nil
+	}
+
+	for _, plugin := range source.SpinVersionFile.Plugins {
+		if plugin.Version != "" {
+			fmt.Printf("This project requires the %q plugin, version %s: spin plugins install %s --version %s\n", plugin.Name, plugin.Version, plugin.Name, plugin.Version)
+		} else {
+			fmt.Printf("This project requires the %q plugin: spin plugins install %s\n", plugin.Name, plugin.Name)
 		}
+	}
+
+	for _, template := range source.SpinVersionFile.Templates {
+		fmt.Printf("This project requires the %q template\n", template.String())
+	}
+
+	return nil
+}
 
-		for _, file := range dirFiles {
-			// Checking if the Spin binary has previously been unpacked...
-			if file.Name() == version {
-				fmt.Printf("Spin version %s found locally.\n", version)
-				versionFolderExists = true
-				break
+// downloadSpinVersions downloads several versions of Spin using up to jobs concurrent downloads. Every version is attempted,
+// and the failures are summarized at the end.
+func downloadSpinVersions(versionDir string, versions []string, jobs int) error {
+	progress := newProgressReporter(os.Stdout)
+	defer progress.Close()
+
+	return runDownloads(versions, jobs, func(version string) error {
+		return downloadSpin(versionDir, version, progress)
+	})
+}
+
+// runDownloads calls download for every version from up to jobs goroutines. A failed download doesn't stop the others,
+// and the failures are returned together once all of them have finished.
+func runDownloads(versions []string, jobs int, download func(version string) error) error {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	errs := make([]error, len(versions))
+	queue := make(chan int)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < jobs && worker < len(versions); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range queue {
+				errs[i] = download(versions[i])
 			}
+		}()
+	}
+
+	for i := range versions {
+		queue <- i
+	}
+	close(queue)
+
+	wg.Wait()
+
+	var failures []string
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("  %s: %v", versions[i], err))
 		}
 	}
 
-	if !versionFolderExists {
-		fmt.Printf("Spin version %s not found locally. Attempting to retrieve from source...\n", version)
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to download %d of %d Spin versions:\n%s", len(failures), len(versions), strings.Join(failures, "\n"))
+	}
+
+	return nil
+}
+
+// downloadSpin will retrieve the desired version of Spin if it is not present in the version directory.
+// Progress is reported to the given reporter, or to standard output if it is nil.
+func downloadSpin(versionDir, version string, progress *progressReporter) error {
+	if progress == nil {
+		progress = newProgressReporter(os.Stdout)
+		defer progress.Close()
+	}
+
+	spinOS, spinArch, variant, err := spinPlatform()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		return err
+	}
+
+	// Determines if we need to pull the file from GitHub
+	repository := verman.NewRepositoryForOS(versionDir, targetOS)
+
+	versionFolderExists, err := repository.IsInstalled(version)
+	if err != nil {
+		return err
+	}
+
+	if versionFolderExists {
+		progress.Logf("Spin version %s found locally.\n", version)
+
+		// An installed version isn't replaced by another build variant, but a user asking for one should know
+		if spinVariant != verman.VariantAuto {
+			installed, err := repository.Get(version)
+			if err == nil && installed != nil && installed.Metadata.Variant != "" && installed.Metadata.Variant != variant {
+				progress.Logf("Spin version %s is the %s build; remove it first to install the %s build.\n", version, installed.Metadata.Variant, variant)
+			}
+		}
+	} else {
+		progress.Logf("Spin version %s not found locally. Attempting to retrieve from source...\n", version)
 
 		if version != "canary" && !semver.IsValid(version) {
 			if !semver.IsValid("v" + version) {
@@ -190,98 +405,382 @@ func downloadSpin(versionDir, version string) error {
 			}
 		}
 
-		fileName := fmt.Sprintf("spin-%s-%s-%s.tar.gz", version, spinOS, spinArch)
+		fileName := verman.ReleaseAssetName(version, spinOS, spinArch)
 
-		resp, err := http.Get("https://github.com/fermyon/spin/releases/download/" + version + "/" + fileName)
+		cacheDir, err := getDownloadCacheDir()
 		if err != nil {
 			return err
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("the version number provided is invalid: %s", version)
+		// Another process downloading the same archive would race on the same cache files
+		cacheLock, err := acquireLock(filepath.Join(cacheDir, fileName+".lock"))
+		if err != nil {
+			return err
 		}
+		defer cacheLock.Release()
 
-		out, err := os.Create(path.Join(versionDir, fileName))
-		if err != nil {
+		// The version may have been installed by another process while waiting for the lock
+		if installed, err := repository.IsInstalled(version); err != nil || installed {
+			if installed {
+				progress.Logf("Spin version %s found locally.\n", version)
+			}
 			return err
 		}
-		defer out.Close()
 
-		_, err = io.Copy(out, resp.Body)
+		archivePath := filepath.Join(cacheDir, version, fileName)
+		source, err := getReleaseSource()
 		if err != nil {
 			return err
 		}
 
-		fmt.Printf("Spin version %s was retrieved successfully!\n", version)
-		if err = unpackSpin(versionDir, fileName, version); err != nil {
+		archiveURL := source.AssetURL(version, fileName)
+		archiveDigestPath := archivePath + ".sha256"
+
+		// A digest recorded when a release archive was cached can be trusted, so a retained archive can be reinstalled
+		// without network access. Canary builds are replaced in place, so their published checksum is always fetched.
+		var expectedDigest string
+		if version != "canary" {
+			expectedDigest, err = verman.ReadDigestFile(archiveDigestPath, fileName)
+			if err != nil {
+				return err
+			}
+		}
+
+		if expectedDigest == "" {
+			if offline {
+				return fmt.Errorf("Spin version %s can't be downloaded in offline mode", version)
+			}
+
+			expectedDigest, err = getExpectedChecksum(version, fileName)
+			if err != nil {
+				return err
+			}
+		}
+
+		cachedDigest, err := verman.FileSHA256(archivePath)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		if cachedDigest == expectedDigest {
+			progress.Logf("Using cached archive for Spin version %s\n", version)
+		} else if offline {
+			return fmt.Errorf("Spin version %s can't be downloaded in offline mode", version)
+		} else {
+			actualDigest, err := downloadFile(archiveURL, archivePath, fileName, progress)
+			if err != nil {
+				return err
+			}
+
+			if actualDigest != expectedDigest {
+				os.Remove(archivePath)
+				return fmt.Errorf("checksum mismatch for %s: expected %s, got %s; the download has been deleted", fileName, expectedDigest, actualDigest)
+			}
+
+			progress.Logf("Spin version %s was retrieved and verified successfully!\n", version)
+		}
+
+		if err := verman.WriteDigestFile(archiveDigestPath, fileName, expectedDigest); err != nil {
+			return err
+		}
+
+		if verifySignature {
+			if err := verifySpinSignature(archivePath, version); err != nil {
+				os.Remove(archivePath)
+				os.Remove(archiveDigestPath)
+				return err
+			}
+
+			progress.Logf("Signature for Spin version %s was verified successfully!\n", version)
+		}
+
+		metadata := &verman.Metadata{
+			Version:       version,
+			Kind:          verman.KindRelease,
+			Source:        archiveURL,
+			ArchiveSHA256: expectedDigest,
+			Variant:       variant,
+		}
+		if version == "canary" {
+			metadata.Kind = verman.KindCanary
+		}
+		if crossPlatform() {
+			metadata.Platform = targetOS + "/" + targetArch
+		}
+
+		if err = unpackSpin(versionDir, archivePath, metadata); err != nil {
 			return err
 		}
+
+		// The version's cache directory is shared with the archives of other platforms, which may be downloading, so only
+		// this archive is removed
+		if !keepArchives {
+			for _, path := range []string{archivePath, archiveDigestPath} {
+				if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+			}
+
+			os.Remove(filepath.Dir(archivePath))
+		}
 	}
 
 	return nil
 }
 
-// unpackSpin unpacks the binary file from a .tar.gz file for the specified version of Spin
-func unpackSpin(directory, tarGzFileName, version string) error {
-	if err := os.Chdir(directory); err != nil {
+// spinPlatform returns the names Spin's release assets use for the target OS and architecture, and the build variant
+// selected with --variant. By default the variant is detected from the system, unless downloading for another platform.
+func spinPlatform() (string, string, string, error) {
+	return verman.SpinPlatform(targetOS, targetArch, spinVariant, func() string {
+		if crossPlatform() {
+			return verman.VariantGlibc
+		}
+		return verman.DetectLinuxVariant("/")
+	})
+}
+
+// crossPlatform indicates whether Spin is being downloaded for another platform than this machine's
+func crossPlatform() bool {
+	return targetOS != runtime.GOOS || targetArch != runtime.GOARCH
+}
+
+// getTargetVersionDir returns the versions directory for the target platform selected with --os and --arch: the
+// versions directory itself, or a platform-qualified directory in it for another platform
+func getTargetVersionDir() (string, error) {
+	if !crossPlatform() {
+		return getVersionDir()
+	}
+
+	// The variant doesn't matter here, so this only checks that Spin is released for the platform
+	if _, _, _, err := verman.SpinPlatform(targetOS, targetArch, verman.VariantAuto, func() string { return verman.VariantGlibc }); err != nil {
+		return "", err
+	}
+
+	dirs, err := getDirs()
+	if err != nil {
+		return "", err
+	}
+
+	versionDir := dirs.PlatformVersionsDir(targetOS, targetArch)
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		return "", err
+	}
+
+	return versionDir, nil
+}
+
+// exportSpin writes the binary of a version of Spin for the target platform to outputPath (or into it, if it is a
+// directory) instead of installing it. A version that is already installed in versionDir is copied from there.
+func exportSpin(versionDir, version, outputPath string) error {
+	binaryName := verman.BinaryName(targetOS)
+
+	name, err := verman.NewRepositoryForOS(versionDir, targetOS).Lookup(version)
+	if err != nil {
+		return err
+	}
+
+	sourceDir := versionDir
+	if name == "" {
+		// The version is installed into a temporary versions directory, which reuses the download cache and verification
+		tempDir, err := os.MkdirTemp("", "spin-verman-export-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tempDir)
+
+		if err := downloadSpin(tempDir, version, nil); err != nil {
+			return err
+		}
+
+		if name, err = verman.NewRepositoryForOS(tempDir, targetOS).Lookup(version); err != nil || name == "" {
+			return fmt.Errorf("Spin version %s could not be exported: %v", version, err)
+		}
+
+		sourceDir = tempDir
+	}
+
+	if info, err := os.Stat(outputPath); err == nil && info.IsDir() {
+		outputPath = filepath.Join(outputPath, binaryName)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return err
+	}
+
+	if err := verman.CopyFile(filepath.Join(sourceDir, name, binaryName), outputPath); err != nil {
 		return err
 	}
 
-	gzipStream, err := os.ReadFile(tarGzFileName)
+	fmt.Printf("Spin version %s for %s/%s was exported to %s\n", name, targetOS, targetArch, outputPath)
+	return nil
+}
+
+// getExpectedChecksum returns the published SHA-256 digest of the given release asset
+func getExpectedChecksum(version, fileName string) (string, error) {
+	checksumsFileName := verman.ChecksumsFileName(version)
+
+	source, err := getReleaseSource()
+	if err != nil {
+		return "", err
+	}
+
+	client, err := getHTTPClient()
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Get(source.AssetURL(version, checksumsFileName))
+	if err != nil {
+		return "", fmt.Errorf("unable to retrieve checksums for Spin version %s: %v", version, err)
+	}
+	defer resp.Body.Close()
+
+	if err := verman.RateLimitError(resp); err != nil {
+		return "", fmt.Errorf("unable to retrieve %s for Spin version %s: %v", checksumsFileName, version, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to retrieve %s for Spin version %s (status %d); refusing to install an unverified binary", checksumsFileName, version, resp.StatusCode)
+	}
+
+	checksums, err := verman.ParseChecksums(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse %s: %v", checksumsFileName, err)
+	}
+
+	digest, ok := checksums[fileName]
+	if !ok {
+		return "", fmt.Errorf("no checksum for %s was found in %s", fileName, checksumsFileName)
+	}
+
+	return digest, nil
+}
+
+// verifySpinSignature checks the cosign signature bundled in a Spin release archive before anything from it is installed
+func verifySpinSignature(archivePath, version string) error {
+	binaryName := verman.BinaryName(targetOS)
+
+	tempDir, err := os.MkdirTemp("", "spin-verman-verify-")
 	if err != nil {
 		return err
 	}
+	defer os.RemoveAll(tempDir)
+
+	wanted := []string{binaryName, verman.SignatureFileName, verman.CertificateFileName}
 
-	uncompressedStream, err := gzip.NewReader(bytes.NewReader(gzipStream))
+	found, err := verman.ExtractFiles(archivePath, tempDir, wanted...)
 	if err != nil {
 		return err
 	}
 
-	tarReader := tar.NewReader(uncompressedStream)
+	if found != len(wanted) {
+		return fmt.Errorf("the archive for Spin version %s does not contain a signature and certificate to verify", version)
+	}
 
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
-		}
+	return verman.VerifySignature(
+		filepath.Join(tempDir, binaryName),
+		filepath.Join(tempDir, verman.SignatureFileName),
+		filepath.Join(tempDir, verman.CertificateFileName),
+		version,
+	)
+}
 
+// unpackSpin unpacks the binary file from a release archive for the specified version of Spin and installs it (see
+// installSpin)
+func unpackSpin(directory, archivePath string, metadata *verman.Metadata) error {
+	return installSpin(directory, metadata, false, func(tempDir, binaryName string) error {
+		found, err := verman.ExtractFiles(archivePath, tempDir, binaryName)
 		if err != nil {
-			return fmt.Errorf("unpackSpin: Next() failed: %w", err)
+			return err
 		}
 
-		// Extracting only the Spin CLI binary
-		if header.Typeflag == tar.TypeReg && header.Name == "spin" {
-			// Create the file with the original permissions
-			outFile, err := os.OpenFile(header.Name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
-			if err != nil {
-				return err
-			}
+		if found == 0 {
+			return fmt.Errorf("the archive for Spin version %s does not contain a Spin binary", metadata.Version)
+		}
 
-			if _, err := io.Copy(outFile, tarReader); err != nil {
-				return err
-			}
-			outFile.Close()
+		return nil
+	})
+}
 
-			// Ensure the file has the correct permissions
-			if err := os.Chmod(header.Name, os.FileMode(header.Mode)); err != nil {
-				return fmt.Errorf("unpackSpin: could not set file permissions: %w", err)
-			}
-		}
+// installSpin installs a version of Spin whose binary is written by writeBinary. The binary is written into a private
+// temporary directory in the version directory, which is then renamed into place in a single atomic step, so an
+// interrupted install never leaves a partially installed version behind. The metadata is completed with the details of
+// the installed binary and recorded alongside it. An installed version of the same name is kept, unless replace is set.
+func installSpin(directory string, metadata *verman.Metadata, replace bool, writeBinary func(tempDir, binaryName string) error) error {
+	binaryName := verman.BinaryName(targetOS)
+
+	version := metadata.Version
+	repository := verman.NewRepositoryForOS(directory, targetOS)
+	repository.RemoveStaleInstallDirs(staleInstallAge)
+
+	tempDir, err := os.MkdirTemp(directory, verman.InstallDirPrefix+version+"-")
+	if err != nil {
+		return err
 	}
+	defer os.RemoveAll(tempDir)
 
-	// Create a folder named with the relevant Spin version
-	if err := os.MkdirAll(version, 0755); err != nil {
+	// MkdirTemp creates the directory with 0700 permissions
+	if err := os.Chmod(tempDir, 0755); err != nil {
 		return err
 	}
 
-	if err := os.Rename("spin", path.Join(directory, version, "spin")); err != nil {
+	if err := writeBinary(tempDir, binaryName); err != nil {
 		return err
 	}
 
-	if err := os.Remove(tarGzFileName); err != nil {
+	// Recording the digest of the installed binary allows it to be re-verified later
+	binaryDigest, err := verman.FileSHA256(filepath.Join(tempDir, binaryName))
+	if err != nil {
 		return err
 	}
 
-	return nil
+	if err := verman.WriteDigestFile(filepath.Join(tempDir, verman.DigestFileName), binaryName, binaryDigest); err != nil {
+		return err
+	}
+
+	binaryInfo, err := os.Stat(filepath.Join(tempDir, binaryName))
+	if err != nil {
+		return err
+	}
+
+	metadata.SHA256 = binaryDigest
+	metadata.Size = binaryInfo.Size()
+	metadata.InstalledAt = time.Now().UTC()
+
+	// Canary is replaced in place, so its commit is recorded to tell builds apart once they are kept as snapshots
+	if metadata.Kind == verman.KindCanary && !crossPlatform() {
+		metadata.Commit, metadata.BuildDate = spinBuildInfo(filepath.Join(tempDir, binaryName))
+	}
+
+	if err := verman.WriteMetadata(tempDir, metadata); err != nil {
+		return err
+	}
+
+	lock, err := lockVersionDir()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	// Another process may have installed the same version in the meantime
+	installed, err := repository.IsInstalled(version)
+	if err != nil || (installed && !replace) {
+		return err
+	}
+
+	// A replaced version is moved aside first, so that it is only removed once the new one is in place
+	if installed {
+		oldDir := tempDir + "-old"
+		if err := os.Rename(filepath.Join(directory, version), oldDir); err != nil {
+			return err
+		}
+		defer os.RemoveAll(oldDir)
+	}
+
+	// A version directory without a binary is left over from an older, non-atomic install and is replaced
+	if err := os.RemoveAll(filepath.Join(directory, version)); err != nil {
+		return err
+	}
+
+	return os.Rename(tempDir, filepath.Join(directory, version))
 }
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fermyon/verman-plugin/internal/verman"
+)
+
+func TestRunDownloads(t *testing.T) {
+	tests := []struct {
+		name            string
+		versions        []string
+		jobs            int
+		failing         map[string]bool
+		expectedMaxJobs int32
+		expectedError   string
+	}{
+		{name: "Bounded by jobs", versions: []string{"v2.1.0", "v2.2.0", "v2.3.0", "v2.4.0", "v2.5.0", "v2.6.0"}, jobs: 2, expectedMaxJobs: 2},
+		{name: "At least one job", versions: []string{"v2.1.0", "v2.2.0", "v2.3.0"}, jobs: 0, expectedMaxJobs: 1},
+		{name: "More jobs than versions", versions: []string{"v2.1.0", "v2.2.0"}, jobs: 8, expectedMaxJobs: 2},
+		{
+			name:            "Failures are summarized",
+			versions:        []string{"v2.1.0", "v2.2.0", "v2.3.0", "v2.4.0"},
+			jobs:            2,
+			failing:         map[string]bool{"v2.1.0": true, "v2.3.0": true},
+			expectedMaxJobs: 2,
+			expectedError:   "failed to download 2 of 4 Spin versions:\n  v2.1.0: not found\n  v2.3.0: not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inFlight, maxInFlight int32
+			var mu sync.Mutex
+			var attempted []string
+
+			err := runDownloads(tt.versions, tt.jobs, func(version string) error {
+				current := atomic.AddInt32(&inFlight, 1)
+				defer atomic.AddInt32(&inFlight, -1)
+
+				for {
+					max := atomic.LoadInt32(&maxInFlight)
+					if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+						break
+					}
+				}
+
+				time.Sleep(20 * time.Millisecond)
+
+				mu.Lock()
+				attempted = append(attempted, version)
+				mu.Unlock()
+
+				if tt.failing[version] {
+					return errors.New("not found")
+				}
+				return nil
+			})
+
+			if tt.expectedError == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectedError != "" && (err == nil || err.Error() != tt.expectedError) {
+				t.Errorf("expected error: %q, got: %v", tt.expectedError, err)
+			}
+
+			if maxInFlight != tt.expectedMaxJobs {
+				t.Errorf("expected %d concurrent downloads, got: %d", tt.expectedMaxJobs, maxInFlight)
+			}
+
+			// A failure doesn't stop the remaining downloads
+			if len(attempted) != len(tt.versions) {
+				t.Errorf("expected every version to be attempted, got: %v", attempted)
+			}
+		})
+	}
+}
+
+func TestInstallSpin(t *testing.T) {
+	writeContent := func(content string) func(tempDir, binaryName string) error {
+		return func(tempDir, binaryName string) error {
+			return os.WriteFile(filepath.Join(tempDir, binaryName), []byte(content), 0755)
+		}
+	}
+
+	tests := []struct {
+		name            string
+		existing        map[string]string // Files of an existing version directory
+		replace         bool
+		writeBinary     func(tempDir, binaryName string) error
+		expectError     bool
+		expectedBinary  string // Empty if no version should be installed
+		expectedMissing []string
+	}{
+		{name: "Install", writeBinary: writeContent("new"), expectedBinary: "new"},
+		{name: "Failed write", writeBinary: func(tempDir, binaryName string) error { return errors.New("disk full") }, expectError: true},
+		{
+			name:            "Replace",
+			existing:        map[string]string{spinBinary: "old", "old.txt": "only in the old version"},
+			replace:         true,
+			writeBinary:     writeContent("new"),
+			expectedBinary:  "new",
+			expectedMissing: []string{"old.txt"},
+		},
+		{name: "Already installed", existing: map[string]string{spinBinary: "old"}, writeBinary: writeContent("new"), expectedBinary: "old"},
+		// A directory without a binary is left over from an older, non-atomic install
+		{name: "Incomplete install", existing: map[string]string{"partial.txt": ""}, writeBinary: writeContent("new"), expectedBinary: "new", expectedMissing: []string{"partial.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dirs := useTestHome(t)
+			versionDir := dirs.VersionsDir()
+			installDir := filepath.Join(versionDir, "build-main-1a2b3c4")
+
+			if err := os.MkdirAll(versionDir, 0755); err != nil {
+				t.Fatalf("failed to create versions directory: %v", err)
+			}
+
+			for name, content := range tt.existing {
+				if err := os.MkdirAll(installDir, 0755); err != nil {
+					t.Fatalf("failed to create version directory: %v", err)
+				}
+				if err := os.WriteFile(filepath.Join(installDir, name), []byte(content), 0755); err != nil {
+					t.Fatalf("failed to write %s: %v", name, err)
+				}
+			}
+
+			metadata := &verman.Metadata{Version: "build-main-1a2b3c4", Kind: verman.KindBuild}
+
+			err := installSpin(versionDir, metadata, tt.replace, tt.writeBinary)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error: %v, got: %v", tt.expectError, err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(installDir, spinBinary))
+			if tt.expectedBinary == "" {
+				if _, err := os.Stat(installDir); !os.IsNotExist(err) {
+					t.Errorf("expected no version directory, got: %v", err)
+				}
+			} else if err != nil || string(content) != tt.expectedBinary {
+				t.Errorf("expected binary %q, got: %q (error: %v)", tt.expectedBinary, content, err)
+			}
+
+			for _, name := range tt.expectedMissing {
+				if _, err := os.Stat(filepath.Join(installDir, name)); !os.IsNotExist(err) {
+					t.Errorf("expected %s to be removed, got: %v", name, err)
+				}
+			}
+
+			// Neither the temporary install directory nor a replaced version is left behind
+			entries, err := os.ReadDir(versionDir)
+			if err != nil {
+				t.Fatalf("failed to read versions directory: %v", err)
+			}
+			for _, entry := range entries {
+				if strings.HasPrefix(entry.Name(), verman.InstallDirPrefix) {
+					t.Errorf("expected no temporary directories, got: %s", entry.Name())
+				}
+			}
+
+			if tt.expectedBinary == "new" {
+				installed, err := verman.NewRepository(versionDir).Get(metadata.Version)
+				if err != nil || installed == nil {
+					t.Fatalf("expected the version to be installed, got: %v", err)
+				}
+				if installed.Metadata.SHA256 != sha256Hex("new") {
+					t.Errorf("expected the digest of the new binary to be recorded, got: %s", installed.Metadata.SHA256)
+				}
+			}
+		})
+	}
+}
@@ -3,59 +3,149 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"strings"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
 
+	"github.com/fermyon/verman-plugin/internal/verman"
 	"github.com/spf13/cobra"
 )
 
+// listOutput is the output format of "list"
+var listOutput string
+
 var listCmd = &cobra.Command{
 	Use:     "list",
 	Aliases: []string{"ls"},
 	Short:   "Lists available Spin versions and aliases.",
+	Long:    "Lists the installed Spin versions (in semver order), followed by canary (and previous canary builds), builds and aliases. The version that \"current_version\" points to is marked with a \"*\".",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		output, err := list()
+		if err := validateOutputFormat(listOutput); err != nil {
+			return err
+		}
+
+		versionDir, err := getTargetVersionDir()
 		if err != nil {
 			return err
 		}
 
-		fmt.Println(output)
+		versions, err := verman.NewRepositoryForOS(versionDir, targetOS).List()
+		if err != nil {
+			return err
+		}
 
-		return nil
+		current := currentVersion(versionDir)
+
+		switch listOutput {
+		case outputJSON:
+			return printJSON(listedVersions(versions, current))
+		case outputPlain:
+			for _, version := range versions {
+				fmt.Println(version.Name)
+			}
+			return nil
+		}
+
+		if len(versions) == 0 {
+			fmt.Println("No versions of Spin were found in the verman versions directory. Run \"spin verman get --help\" to get started")
+			return nil
+		}
+
+		return printVersionTable(versions, current)
 	},
 }
 
-// list prints all subdirectories (excluding current_version) in ~/.spin_verman/versions
-func list() (string, error) {
-	versionDir, err := getVersionDir()
-	if err != nil {
-		return "", err
-	}
+// listedVersion is the JSON representation of an installed version
+type listedVersion struct {
+	Name        string    `json:"name"`
+	Kind        string    `json:"kind"`
+	Variant     string    `json:"variant,omitempty"`
+	Current     bool      `json:"current"`
+	Path        string    `json:"path"`
+	Target      string    `json:"target,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	Commit      string    `json:"commit,omitempty"`
+	BuildDate   string    `json:"build_date,omitempty"`
+	SHA256      string    `json:"sha256,omitempty"`
+	Size        int64     `json:"size"`
+	InstalledAt time.Time `json:"installed_at"`
+}
 
-	pathExists, err := exists(versionDir)
-	if err != nil {
-		return "", err
-	}
+func listedVersions(versions []*verman.InstalledVersion, current string) []listedVersion {
+	listed := []listedVersion{}
 
-	if !pathExists {
-		return "", nil
-	}
+	for _, version := range versions {
+		entry := listedVersion{
+			Name:        version.Name,
+			Kind:        version.Metadata.Kind,
+			Variant:     version.Metadata.Variant,
+			Current:     version.Name == current,
+			Path:        version.BinaryPath(),
+			Commit:      version.Metadata.Commit,
+			BuildDate:   version.Metadata.BuildDate,
+			SHA256:      version.Metadata.SHA256,
+			Size:        version.Metadata.Size,
+			InstalledAt: version.Metadata.InstalledAt,
+		}
 
-	files, err := os.ReadDir(versionDir)
-	if err != nil {
-		return "", err
+		if version.Metadata.Kind == verman.KindAlias {
+			entry.Target = version.Metadata.Source
+		} else {
+			entry.Source = version.Metadata.Source
+		}
+
+		listed = append(listed, entry)
 	}
 
-	var output []string
+	return listed
+}
+
+// printVersionTable prints the installed versions as a table, marking the current version with a "*"
+func printVersionTable(versions []*verman.InstalledVersion, current string) error {
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "\tVERSION\tKIND\tVARIANT\tINSTALLED\tSIZE\tTARGET")
+
+	for _, version := range versions {
+		marker := ""
+		if version.Name == current {
+			marker = "*"
+		}
+
+		size := "-"
+		if version.Metadata.Size > 0 {
+			size = formatBytes(version.Metadata.Size)
+		}
+
+		variant := "-"
+		if version.Metadata.Variant != "" {
+			variant = version.Metadata.Variant
+		}
 
-	for _, file := range files {
-		if file.Name() != "current_version" {
-			output = append(output, file.Name())
+		target := ""
+		if version.Metadata.Kind == verman.KindAlias {
+			target = version.Metadata.Source
 		}
+
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", marker, version.Name, version.Metadata.Kind, variant, version.Metadata.InstalledAt.Local().Format(time.DateOnly), size, target)
+	}
+
+	return table.Flush()
+}
+
+// currentVersion returns the name of the version that "current_version" points to (or the shim's global fallback
+// version when the shim is enabled), or an empty string if none has been set
+func currentVersion(versionDir string) string {
+	symlinkDir := filepath.Join(versionDir, verman.CurrentVersionDirName)
+
+	if enabled, err := shimEnabled(symlinkDir); err == nil && enabled {
+		global, _ := readShimGlobal(symlinkDir)
+		return global
 	}
 
-	if len(output) == 0 {
-		fmt.Println("No versions of Spin were found in the \"~/.spin_verman/versions\" directory. Run \"spin verman get --help\" to get started")
+	target, err := binaryLinker.Target(filepath.Join(symlinkDir, spinBinary))
+	if err != nil || !samePath(filepath.Dir(filepath.Dir(target)), versionDir) {
+		return ""
 	}
 
-	return strings.Join(output, "\n"), nil
+	return filepath.Base(filepath.Dir(target))
 }
@@ -1,22 +1,41 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"log"
-	"net/http"
 	"os"
 	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/fermyon/verman-plugin/internal/verman"
 	"github.com/spf13/cobra"
 )
 
+// Flags of "list-remote"
+var (
+	listRemoteFilter verman.ReleaseFilter
+	listRemoteSince  string
+	listRemoteOutput string
+)
+
 var listRemoteCmd = &cobra.Command{
 	Use:     "list-remote",
 	Aliases: []string{"ls-remote"},
 	Short:   "Lists all available versions of Spin",
+	Long:    "Lists all available versions of Spin with their publish date, whether a binary is published for this OS and architecture, and whether they are installed locally.",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(listRemoteOutput); err != nil {
+			return err
+		}
+
+		if listRemoteSince != "" {
+			since, err := verman.ParseSince(listRemoteSince, time.Now())
+			if err != nil {
+				return err
+			}
+			listRemoteFilter.Since = since
+		}
+
 		err := listRemote()
 		if err != nil {
 			fmt.Printf("Error while loading available Spin version\n%v\n", err)
@@ -27,58 +46,85 @@ var listRemoteCmd = &cobra.Command{
 	},
 }
 
-const (
-	spinReleasesUrl   = "https://api.github.com/repos/fermyon/spin/releases"
-	githubTokenEnvVar = "GH_TOKEN"
-)
+// remoteRelease is the JSON representation of a release listed by "list-remote"
+type remoteRelease struct {
+	Version        string    `json:"version"`
+	Prerelease     bool      `json:"prerelease"`
+	PublishedAt    time.Time `json:"published_at"`
+	Asset          string    `json:"asset,omitempty"`
+	AssetAvailable bool      `json:"asset_available"`
+	Installed      bool      `json:"installed"`
+}
 
 func listRemote() error {
-	fmt.Fprintf(os.Stderr, "Fetching available Spin releases ...\n\n")
 	releases, err := loadSpinReleases()
 	if err != nil {
 		return err
 	}
-	for _, release := range *releases {
-		tagName := strings.Replace(release.TagName, "v", "", 1)
-		fmt.Printf("%s\n", tagName)
-	}
-	return nil
-}
 
-func loadSpinReleases() (*[]spinRelease, error) {
-	req, err := http.NewRequest("GET", spinReleasesUrl, nil)
+	releases, err = verman.FilterReleases(releases, listRemoteFilter)
 	if err != nil {
-		log.Fatalf("Failed to create request: %v", err)
-	}
-	token := os.Getenv(githubTokenEnvVar)
-	if len(token) > 0 {
-		req.Header.Set("Authorization", fmt.Sprintf("token %s", token))
+		return err
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	versionDir, err := getVersionDir()
 	if err != nil {
-		return nil, fmt.Errorf("Failed to load available Spin releases")
+		return err
 	}
-	defer resp.Body.Close()
 
-	// the value stored in env GH_TOKEN is a bad credential
-	if resp.StatusCode == http.StatusUnauthorized {
-		return nil, fmt.Errorf("Unauthorized: Bad credentials. Please check your GitHub token (%s).", githubTokenEnvVar)
+	repository := verman.NewRepository(versionDir)
+
+	// Releases are still listed on platforms Spin doesn't publish binaries for, just without any assets
+	spinOS, spinArch, _, platformErr := spinPlatform()
+
+	var listed []remoteRelease
+	for _, release := range releases {
+		entry := remoteRelease{Version: release.TagName, Prerelease: release.Prerelease, PublishedAt: release.PublishedAt}
+
+		if platformErr == nil {
+			entry.Asset = verman.ReleaseAssetName(release.TagName, spinOS, spinArch)
+			entry.AssetAvailable = release.HasAsset(entry.Asset)
+		}
+
+		if entry.Installed, err = repository.IsInstalled(release.TagName); err != nil {
+			return err
+		}
+
+		listed = append(listed, entry)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to read response body: %v", err)
+	switch listRemoteOutput {
+	case outputJSON:
+		if listed == nil {
+			listed = []remoteRelease{}
+		}
+		return printJSON(listed)
+	case outputPlain:
+		for _, release := range listed {
+			fmt.Println(strings.TrimPrefix(release.Version, "v"))
+		}
+		return nil
 	}
-	var releases []spinRelease
-	err = json.Unmarshal(body, &releases)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to unmarshal JSON: %v", err)
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "VERSION\tTYPE\tPUBLISHED\tASSET\tINSTALLED")
+
+	for _, release := range listed {
+		kind := "stable"
+		if release.Prerelease {
+			kind = "prerelease"
+		}
+
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\n", strings.TrimPrefix(release.Version, "v"), kind, release.PublishedAt.Local().Format(time.DateOnly), yesNo(release.AssetAvailable), yesNo(release.Installed))
 	}
-	return &releases, nil
+
+	return table.Flush()
 }
 
-type spinRelease struct {
-	TagName string `json:"tag_name"`
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+
+	return "no"
 }
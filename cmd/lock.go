@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fermyon/verman-plugin/internal/verman"
+)
+
+// lockTimeout is how long to wait for another verman process to release a lock
+var lockTimeout time.Duration
+
+// acquireLock takes the cross-process lock at lockPath, reporting which process holds it if it has to wait
+func acquireLock(lockPath string) (*verman.Lock, error) {
+	return verman.AcquireLock(lockPath, lockTimeout, func(pid int) {
+		if pid > 0 {
+			fmt.Fprintf(os.Stderr, "Waiting for lock held by PID %d (%s)...\n", pid, lockPath)
+		} else {
+			fmt.Fprintf(os.Stderr, "Waiting for lock %s...\n", lockPath)
+		}
+	})
+}
+
+// lockVersionDir takes the lock that serializes changes to the version directory across verman processes.
+// The lock isn't reentrant, so it must be released before calling anything else that takes it.
+func lockVersionDir() (*verman.Lock, error) {
+	dirs, err := getDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dirs.State, 0755); err != nil {
+		return nil, err
+	}
+
+	return acquireLock(versionDirLockPath(dirs))
+}
+
+// versionDirLockPath returns the path of the lock that serializes changes to the version directory
+func versionDirLockPath(dirs verman.Dirs) string {
+	return filepath.Join(dirs.State, "verman.lock")
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// The formats supported by the --output flag of the commands that list versions
+const (
+	outputTable = "table"
+	outputPlain = "plain"
+	outputJSON  = "json"
+)
+
+// validateOutputFormat returns an error if format isn't one of the supported output formats
+func validateOutputFormat(format string) error {
+	switch format {
+	case outputTable, outputPlain, outputJSON:
+		return nil
+	default:
+		return fmt.Errorf("invalid output format %q (expected %s, %s or %s)", format, outputTable, outputPlain, outputJSON)
+	}
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
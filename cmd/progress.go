@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	progressBarWidth      = 30
+	progressRenderPeriod  = 100 * time.Millisecond
+	progressNameMaxLength = 40
+)
+
+// progressReporter reports the progress of concurrent downloads. When attached to a terminal each download is drawn as a
+// progress bar that is redrawn in place; otherwise a line is logged as each download starts and finishes.
+type progressReporter struct {
+	out io.Writer
+	tty bool
+
+	mu    sync.Mutex
+	bars  []*progressBar
+	drawn int // Number of bar lines drawn by the last render, which the next render moves back over
+
+	stop    chan struct{}
+	stopped sync.WaitGroup
+}
+
+// progressBar tracks a single download. It is an io.Writer so it can be attached to the download stream.
+type progressBar struct {
+	reporter *progressReporter
+	name     string
+	total    int64 // -1 when the size of the download is unknown
+	current  int64
+	resumed  int64 // Bytes downloaded by an earlier attempt, which don't count towards the rate
+	started  time.Time
+	finished time.Time
+	failed   bool
+}
+
+// newProgressReporter creates a reporter writing to out. Progress bars are only drawn when out is a terminal.
+func newProgressReporter(out *os.File) *progressReporter {
+	r := &progressReporter{out: out, tty: isTerminal(out), stop: make(chan struct{})}
+
+	if r.tty {
+		r.stopped.Add(1)
+		go r.renderLoop()
+	}
+
+	return r
+}
+
+// isTerminal indicates whether f is an interactive terminal capable of redrawing progress bars
+func isTerminal(f *os.File) bool {
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Logf prints a line of output without disturbing the progress bars
+func (r *progressReporter) Logf(format string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.clear()
+	fmt.Fprintf(r.out, format, args...)
+	r.render()
+}
+
+// Start begins tracking a download of total bytes (or -1 if unknown)
+func (r *progressReporter) Start(name string, total int64) *progressBar {
+	bar := &progressBar{reporter: r, name: name, total: total, started: time.Now()}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.tty {
+		r.bars = append(r.bars, bar)
+	} else if total >= 0 {
+		fmt.Fprintf(r.out, "Downloading %s (%s)\n", name, formatBytes(total))
+	} else {
+		fmt.Fprintf(r.out, "Downloading %s\n", name)
+	}
+
+	return bar
+}
+
+// Close stops redrawing the progress bars, leaving their final state on screen
+func (r *progressReporter) Close() {
+	if !r.tty {
+		return
+	}
+
+	close(r.stop)
+	r.stopped.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.clear()
+	r.render()
+	r.bars = nil
+	r.drawn = 0
+}
+
+func (r *progressReporter) renderLoop() {
+	defer r.stopped.Done()
+
+	ticker := time.NewTicker(progressRenderPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			r.clear()
+			r.render()
+			r.mu.Unlock()
+		}
+	}
+}
+
+// clear moves the cursor back to the first bar line and erases everything below it. r.mu must be held.
+func (r *progressReporter) clear() {
+	if r.drawn > 0 {
+		fmt.Fprintf(r.out, "\x1b[%dA\x1b[J", r.drawn)
+		r.drawn = 0
+	}
+}
+
+// render draws every bar below the cursor. r.mu must be held.
+func (r *progressReporter) render() {
+	if !r.tty {
+		return
+	}
+
+	for _, bar := range r.bars {
+		fmt.Fprintln(r.out, bar.line())
+	}
+
+	r.drawn = len(r.bars)
+}
+
+func (b *progressBar) Write(p []byte) (int, error) {
+	b.reporter.mu.Lock()
+	b.current += int64(len(p))
+	b.reporter.mu.Unlock()
+
+	return len(p), nil
+}
+
+// Resume records that the first offset bytes were downloaded by an earlier, interrupted attempt
+func (b *progressBar) Resume(offset int64) {
+	if offset <= 0 {
+		return
+	}
+
+	r := b.reporter
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b.current += offset
+	b.resumed += offset
+
+	if !r.tty {
+		fmt.Fprintf(r.out, "Resuming %s from %s\n", b.name, formatBytes(offset))
+	}
+}
+
+// Finish marks the download as complete, or as failed if err is not nil
+func (b *progressBar) Finish(err error) {
+	r := b.reporter
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b.finished = time.Now()
+	b.failed = err != nil
+
+	if r.tty {
+		return
+	}
+
+	elapsed := b.finished.Sub(b.started)
+	if b.failed {
+		fmt.Fprintf(r.out, "Failed to download %s after %s: %v\n", b.name, elapsed.Round(time.Millisecond), err)
+	} else {
+		fmt.Fprintf(r.out, "Downloaded %s (%s in %s, %s/s)\n", b.name, formatBytes(b.current), elapsed.Round(time.Millisecond), formatBytes(rate(b.current-b.resumed, elapsed)))
+	}
+}
+
+// line renders the bar as "name [=====>    ] 12.0 MB / 30.0 MB  4.5 MB/s  ETA 4s". b.reporter.mu must be held.
+func (b *progressBar) line() string {
+	name := b.name
+	if len(name) > progressNameMaxLength {
+		name = name[:progressNameMaxLength-3] + "..."
+	}
+
+	end := time.Now()
+	if !b.finished.IsZero() {
+		end = b.finished
+	}
+	elapsed := end.Sub(b.started)
+	bytesPerSecond := rate(b.current-b.resumed, elapsed)
+
+	var bar, size, status string
+
+	if b.total > 0 {
+		filled := int(float64(progressBarWidth) * float64(b.current) / float64(b.total))
+		if filled > progressBarWidth {
+			filled = progressBarWidth
+		}
+
+		bar = strings.Repeat("=", filled)
+		if filled < progressBarWidth {
+			bar += ">" + strings.Repeat(" ", progressBarWidth-filled-1)
+		}
+
+		size = fmt.Sprintf("%s / %s", formatBytes(b.current), formatBytes(b.total))
+	} else {
+		bar = strings.Repeat("-", progressBarWidth)
+		size = formatBytes(b.current)
+	}
+
+	switch {
+	case b.failed:
+		status = "failed"
+	case !b.finished.IsZero():
+		status = fmt.Sprintf("done in %s", elapsed.Round(100*time.Millisecond))
+	case b.total > 0 && bytesPerSecond > 0:
+		remaining := time.Duration(float64(b.total-b.current) / float64(bytesPerSecond) * float64(time.Second))
+		status = fmt.Sprintf("ETA %s", remaining.Round(time.Second))
+	}
+
+	return fmt.Sprintf("%-*s [%s] %s  %s/s  %s", progressNameMaxLength, name, bar, size, formatBytes(bytesPerSecond), status)
+}
+
+func rate(n int64, elapsed time.Duration) int64 {
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return int64(float64(n) / elapsed.Seconds())
+}
+
+// formatBytes formats a byte count using binary units, e.g. "30.1 MB"
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
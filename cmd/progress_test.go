@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestProgressReporterLines(t *testing.T) {
+	// A file isn't a terminal, so a line is logged for each event instead of drawing progress bars
+	out, err := os.Create(filepath.Join(t.TempDir(), "progress.log"))
+	if err != nil {
+		t.Fatalf("failed to create output file: %v", err)
+	}
+	defer out.Close()
+
+	progress := newProgressReporter(out)
+	if progress.tty {
+		t.Fatalf("expected a file not to be treated as a terminal")
+	}
+
+	progress.Logf("Spin version %s not found locally.\n", "v2.7.0")
+
+	bar := progress.Start("spin-v2.7.0-linux-amd64.tar.gz", 2048)
+	bar.Resume(1024)
+	bar.Write(make([]byte, 1024))
+	bar.Finish(nil)
+
+	failed := progress.Start("spin-canary-linux-amd64.tar.gz", -1)
+	failed.Finish(errors.New("connection reset"))
+
+	progress.Close()
+
+	content, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+
+	expected := []*regexp.Regexp{
+		regexp.MustCompile(`^Spin version v2\.7\.0 not found locally\.$`),
+		regexp.MustCompile(`^Downloading spin-v2\.7\.0-linux-amd64\.tar\.gz \(2\.0 KB\)$`),
+		regexp.MustCompile(`^Resuming spin-v2\.7\.0-linux-amd64\.tar\.gz from 1\.0 KB$`),
+		regexp.MustCompile(`^Downloaded spin-v2\.7\.0-linux-amd64\.tar\.gz \(2\.0 KB in \S+, \S+ \S+/s\)$`),
+		regexp.MustCompile(`^Downloading spin-canary-linux-amd64\.tar\.gz$`),
+		regexp.MustCompile(`^Failed to download spin-canary-linux-amd64\.tar\.gz after \S+: connection reset$`),
+	}
+
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines, got: %q", len(expected), lines)
+	}
+
+	for i, pattern := range expected {
+		if !pattern.MatchString(lines[i]) {
+			t.Errorf("expected line %d to match %s, got: %q", i+1, pattern, lines[i])
+		}
+	}
+
+	// Nothing that only makes sense on a terminal is written
+	if strings.Contains(string(content), "\x1b[") {
+		t.Errorf("expected no escape sequences, got: %q", content)
+	}
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var refreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Refreshes the cached index of available Spin releases.",
+	Long:  "Refreshes the cached index of available Spin releases, which is used to resolve versions (including in offline mode) and list remote releases.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		index, err := getReleaseIndex(true)
+		if err != nil {
+			return err
+		}
+
+		latest, err := index.LatestStable()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Release index updated: %d releases, latest stable version %s\n", len(index.Releases), latest)
+
+		return nil
+	},
+}
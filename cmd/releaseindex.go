@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fermyon/verman-plugin/internal/verman"
+)
+
+var (
+	// offline resolves versions purely from the cached release index and installed versions, without network access
+	offline bool
+
+	// releaseIndexTTL is how long the cached release index is used before it is revalidated
+	releaseIndexTTL time.Duration
+)
+
+// loadSpinReleases returns every published Spin release from the release index
+func loadSpinReleases() ([]verman.Release, error) {
+	index, err := getReleaseIndex(false)
+	if err != nil {
+		return nil, err
+	}
+
+	return index.Releases, nil
+}
+
+// getReleaseIndexPath returns the path of the cached release index
+func getReleaseIndexPath() (string, error) {
+	dirs, err := getDirs()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dirs.Cache, verman.ReleaseIndexFileName), nil
+}
+
+// getReleaseIndex returns the cached release index, refreshing it first if it is older than the TTL (or if refresh is
+// set). A stale index is revalidated with its ETag so an unchanged index isn't downloaded again. In offline mode the
+// cached index is always used, and if the releases can't be fetched a stale index is used with a warning.
+func getReleaseIndex(refresh bool) (*verman.ReleaseIndex, error) {
+	indexPath, err := getReleaseIndexPath()
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := getReleaseSource()
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := verman.ReadReleaseIndex(indexPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring the cached release index: %v\n", err)
+		index = nil
+	}
+
+	// An index cached from a different release source doesn't describe the releases available from this one
+	if index != nil && index.Source != source.String() {
+		index = nil
+	}
+
+	if offline {
+		if refresh {
+			return nil, fmt.Errorf("the release index can't be refreshed in offline mode")
+		}
+
+		if index == nil {
+			return nil, fmt.Errorf("no release index has been cached for %s; run \"spin verman refresh\" while online", source)
+		}
+
+		return index, nil
+	}
+
+	if index != nil && !refresh && index.Fresh(releaseIndexTTL, time.Now()) {
+		return index, nil
+	}
+
+	etag := ""
+	if index != nil {
+		etag = index.ETag
+	}
+
+	fmt.Fprintf(os.Stderr, "Fetching available Spin releases ...\n")
+
+	releases, newETag, notModified, err := source.ListReleases(etag)
+	if err != nil {
+		if index == nil || refresh {
+			return nil, err
+		}
+
+		fmt.Fprintf(os.Stderr, "Warning: using the release index cached at %s: %v\n", index.FetchedAt.Local().Format(time.DateTime), err)
+		return index, nil
+	}
+
+	if notModified {
+		index.FetchedAt = time.Now().UTC()
+	} else {
+		index = &verman.ReleaseIndex{Source: source.String(), FetchedAt: time.Now().UTC(), ETag: newETag, Releases: releases}
+	}
+
+	if err := verman.WriteReleaseIndex(indexPath, index); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to cache the release index: %v\n", err)
+	}
+
+	return index, nil
+}
@@ -4,9 +4,10 @@ import (
 	"bufio"
 	"fmt"
 	"os"
-	"path"
+	"path/filepath"
 	"strings"
 
+	"github.com/fermyon/verman-plugin/internal/verman"
 	"github.com/spf13/cobra"
 )
 
@@ -14,7 +15,7 @@ var removeCmd = &cobra.Command{
 	Use:     "remove",
 	Aliases: []string{"rm"},
 	Short:   "Removes the specified Spin version (or symlink if it's an alias) from the local directory.",
-	Long:    "Removes the specified Spin version (or symlink if it's an alias) from the local directory. Only removes the relevant Spin binary located in the \"~/.spin_verman/versions\" directory.",
+	Long:    "Removes the specified Spin version (or symlink if it's an alias) from the local directory. Only removes the relevant Spin binary located in the verman versions directory.",
 	Args:    cobra.MaximumNArgs(1), // This intentionally only removes one at a time
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
@@ -47,7 +48,7 @@ var removeCurrentCmd = &cobra.Command{
 var removeAllCmd = &cobra.Command{
 	Use:   "all",
 	Short: "Removes all Spin versions (or symlinks if there are aliases) from the local directory.",
-	Long:  "Removes all Spin versions (or symlinks if there are aliases) from the local directory. Only removes the Spin binaries located in the \"~/.spin_verman/versions\" directory.",
+	Long:  "Removes all Spin versions (or symlinks if there are aliases) from the local directory. Only removes the Spin binaries located in the verman versions directory.",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		fmt.Print("Are you sure you want to delete all Spin versions?\nType \"y\", \"yes\", or any other key to cancel: ")
 		input := bufio.NewScanner(os.Stdin)
@@ -71,23 +72,33 @@ var removeAllCmd = &cobra.Command{
 
 // remove removes the associated version file and directory. If the path isn't found, this will not return an error.
 func remove(version string) error {
-	versionDir, err := getVersionDir()
+	versionDir, err := getTargetVersionDir()
 	if err != nil {
 		return err
 	}
 
-	// Ensures that versions passed without a `v` prefix are deleted
-	versionPath := path.Join(versionDir, version)
-	if _, err := os.Stat(versionPath); os.IsNotExist(err) {
-		versionPath = path.Join(versionDir, "v"+version)
-		if _, err := os.Stat(versionPath); os.IsNotExist(err) {
+	filePath := filepath.Join(versionDir, version)
+
+	if version != verman.CurrentVersionDirName {
+		// Ensures that versions passed without a `v` prefix are deleted
+		name, err := verman.NewRepositoryForOS(versionDir, targetOS).Lookup(version)
+		if err != nil {
+			return err
+		}
+
+		if name == "" {
 			fmt.Println("Warning: file does not exist; nothing to remove")
 			return nil
 		}
-		version = "v" + version
+
+		filePath = filepath.Join(versionDir, name)
 	}
 
-	filePath := path.Join(versionDir, version)
+	lock, err := lockVersionDir()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
 
 	if err := os.RemoveAll(filePath); err != nil {
 		return err
@@ -96,21 +107,26 @@ func remove(version string) error {
 	return nil
 }
 
-// removeAll removes all subdirectories in ~.spin_verman/versions
+// removeAll removes all subdirectories in the versions directory
 func removeAll() error {
-	versionString, err := list()
+	versionDir, err := getTargetVersionDir()
+	if err != nil {
+		return err
+	}
+
+	versions, err := verman.NewRepositoryForOS(versionDir, targetOS).Names()
 	if err != nil {
 		return err
 	}
 
-	for _, version := range strings.Split(versionString, "\n") {
+	for _, version := range versions {
 		if err := remove(version); err != nil {
 			return err
 		}
 	}
 
-	// The list method doesn't return the "current_version" directory, so we need to manually delete it
-	if err := remove("current_version"); err != nil {
+	// The repository doesn't return the "current_version" directory, so we need to manually delete it
+	if err := remove(verman.CurrentVersionDirName); err != nil {
 		return err
 	}
 
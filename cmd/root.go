@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
+	"runtime"
 
+	"github.com/fermyon/verman-plugin/internal/verman"
 	"github.com/spf13/cobra"
 )
 
@@ -12,29 +15,80 @@ var rootCmd = &cobra.Command{
 }
 
 func Execute() {
+	if isShimInvocation() {
+		if err := runShim(os.Args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&vermanHome, "home", "", "Directory to keep all verman files in, instead of the XDG data, cache, config and state directories. Defaults to $"+verman.HomeEnvVar+" if set.")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", verman.OfflineByDefault(), "Resolve versions from the cached release index and installed versions only, without network access. Defaults to true when $"+verman.OfflineEnvVar+" is set to true.")
+	rootCmd.PersistentFlags().DurationVar(&releaseIndexTTL, "release-index-ttl", verman.ReleaseIndexTTLFromEnv(), "How long the cached release index is used before it is refreshed. Defaults to $"+verman.ReleaseIndexTTLEnvVar+" if set.")
+	rootCmd.PersistentFlags().DurationVar(&httpTimeout, "http-timeout", verman.HTTPTimeoutFromEnv(), "How long to wait to connect to a server and for it to respond. Defaults to $"+verman.HTTPTimeoutEnvVar+" if set.")
+	rootCmd.PersistentFlags().IntVar(&httpRetries, "http-retries", verman.HTTPRetriesFromEnv(), "How many times to retry a request that fails with a network or server error. Defaults to $"+verman.HTTPRetriesEnvVar+" if set.")
+	rootCmd.PersistentFlags().DurationVar(&lockTimeout, "lock-timeout", verman.LockTimeoutFromEnv(), "How long to wait for another verman process to release its lock. Defaults to $"+verman.LockTimeoutEnvVar+" if set.")
+
 	// Set
 	setCmd.AddCommand(setLatestStableCmd)
 	rootCmd.AddCommand(setCmd)
 	//Alias
 	rootCmd.AddCommand(aliasCmd)
+	// Build
+	buildCmd.Flags().StringSliceVar(&buildFeatures, "features", nil, "Cargo features to build Spin with, separated by commas. Defaults to \"build.features\" in the configuration file.")
+	buildCmd.Flags().StringVar(&buildRepository, "repository", "", "Git URL of the Spin repository to build from. Defaults to \"build.repository\" in the configuration file, or "+verman.DefaultSpinGitURL+".")
+	rootCmd.AddCommand(buildCmd)
+	// Exec
+	execCmd.Flags().SetInterspersed(false) // Everything after the version belongs to Spin, including flags
+	rootCmd.AddCommand(execCmd)
 	// Get
+	getCmd.Flags().StringVar(&artifactName, "artifact", "", "Name of the GitHub Actions artifact to install for pr/<number> or commit/<sha>, instead of the one selected by its name for the target platform")
+	getCmd.PersistentFlags().StringVar(&exportPath, "export", "", "Write the Spin binary to this file or directory instead of installing it")
+	getCmd.Flags().IntVarP(&downloadJobs, "jobs", "j", 4, "Maximum number of versions to download at the same time")
 	getCmd.AddCommand(getLatestStableCmd)
 	rootCmd.AddCommand(getCmd)
 	// List
+	listCmd.Flags().StringVarP(&listOutput, "output", "o", outputTable, "Output format: table, plain (names only) or json")
 	rootCmd.AddCommand(listCmd)
 	// List Remote
+	listRemoteCmd.Flags().BoolVar(&listRemoteFilter.Prerelease, "prerelease", false, "Only list pre-releases (including canary)")
+	listRemoteCmd.Flags().BoolVar(&listRemoteFilter.Stable, "stable", false, "Only list stable releases")
+	listRemoteCmd.MarkFlagsMutuallyExclusive("prerelease", "stable")
+	listRemoteCmd.Flags().StringVarP(&listRemoteFilter.Constraint, "constraint", "c", "", "Only list releases matching a version constraint, e.g. \"^2.5\"")
+	listRemoteCmd.Flags().StringVar(&listRemoteSince, "since", "", "Only list releases published since a date (2024-06-01) or within an age (30d, 2w)")
+	listRemoteCmd.Flags().StringVarP(&listRemoteOutput, "output", "o", outputTable, "Output format: table, plain (versions only) or json")
 	rootCmd.AddCommand(listRemoteCmd)
+	// Refresh
+	rootCmd.AddCommand(refreshCmd)
 	// Remove
 	removeCmd.AddCommand(removeAllCmd)
 	removeCmd.AddCommand(removeCurrentCmd)
 	rootCmd.AddCommand(removeCmd)
+	// Shim
+	shimCmd.AddCommand(shimEnableCmd)
+	shimCmd.AddCommand(shimDisableCmd)
+	rootCmd.AddCommand(shimCmd)
 	// Update
+	updateCanaryCmd.Flags().IntVar(&canaryKeep, "keep", verman.DefaultCanaryKeep, "How many previous canary builds to keep as \"canary@<date>-<commit>\" (0 keeps none). Defaults to \"canary.keep\" in the configuration file if set.")
 	updateCmd.AddCommand(updateCanaryCmd)
 	rootCmd.AddCommand(updateCmd)
+
+	// Flags for the commands that manage the versions downloaded for another platform
+	for _, c := range []*cobra.Command{getCmd, listCmd, removeCmd} {
+		c.PersistentFlags().StringVar(&targetOS, "os", runtime.GOOS, "OS to manage Spin versions for (linux, darwin or windows). Versions for another platform than this machine's are kept in a separate directory.")
+		c.PersistentFlags().StringVar(&targetArch, "arch", runtime.GOARCH, "Architecture to manage Spin versions for (amd64 or arm64)")
+	}
+
+	// Flags for the commands that download Spin
+	for _, c := range []*cobra.Command{getCmd, setCmd, updateCmd, execCmd} {
+		c.PersistentFlags().BoolVar(&keepArchives, "keep-archives", verman.KeepArchivesByDefault(), "Keep downloaded archives in the download cache so they can be reinstalled without network access. Defaults to true when $"+verman.KeepArchivesEnvVar+" is set to true.")
+		c.PersistentFlags().StringVar(&spinVariant, "variant", verman.VariantFromEnv(), "Build of Spin to download on Linux: glibc, static (linked against musl, e.g. for Alpine) or auto to detect it from the system. Defaults to $"+verman.VariantEnvVar+" if set.")
+		c.PersistentFlags().BoolVar(&verifySignature, "verify-signature", verman.VerifySignatureByDefault(), "Verify the cosign signature of downloaded Spin binaries (requires cosign). Defaults to true when $"+verman.VerifySignatureEnvVar+" is set to true.")
+	}
 }
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/fermyon/verman-plugin/internal/verman"
+)
+
+// useTestHome points verman at a temporary home directory for the duration of a test. The directories, configuration,
+// HTTP client and release source are resolved again for it.
+func useTestHome(t *testing.T) verman.Dirs {
+	t.Helper()
+
+	reset := func(home string) {
+		vermanHome = home
+		dirsOnce, resolvedDirs, dirsErr = sync.Once{}, verman.Dirs{}, nil
+		configOnce, loadedConfig, configErr = sync.Once{}, nil, nil
+		httpClientOnce, httpClient, httpClientErr = sync.Once{}, nil, nil
+		sourceOnce, releaseSource, sourceErr = sync.Once{}, nil, nil
+	}
+
+	reset(t.TempDir())
+	t.Cleanup(func() { reset("") })
+
+	dirs, err := getDirs()
+	if err != nil {
+		t.Fatalf("failed to resolve directories: %v", err)
+	}
+
+	return dirs
+}
+
+// writeTestVersion installs a version whose binary is a shell script with the given body
+func writeTestVersion(t *testing.T, versionDir, version, script string) string {
+	t.Helper()
+
+	binaryPath := filepath.Join(versionDir, version, spinBinary)
+	if err := os.MkdirAll(filepath.Dir(binaryPath), 0755); err != nil {
+		t.Fatalf("failed to create version directory: %v", err)
+	}
+	if err := os.WriteFile(binaryPath, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatalf("failed to write binary: %v", err)
+	}
+
+	return binaryPath
+}
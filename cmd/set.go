@@ -3,7 +3,8 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path"
+	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/fermyon/verman-plugin/internal/verman"
@@ -25,14 +26,33 @@ var setCmd = &cobra.Command{
 			return err
 		}
 
-		symlinkDir := path.Join(versionDir, "current_version")
-		binaryDir := path.Join(versionDir, version)
+		if len(args) == 0 {
+			if err := printVersionSource(); err != nil {
+				return err
+			}
+		}
+
+		version, err = resolveVersion(versionDir, version, os.Stdout)
+		if err != nil {
+			return err
+		}
+
+		symlinkDir := filepath.Join(versionDir, "current_version")
+		binaryDir := filepath.Join(versionDir, version)
 
 		if err := checkPathVar(symlinkDir); err != nil {
 			return err
 		}
 
-		if err := downloadSpin(versionDir, version); err != nil {
+		if err := downloadSpin(versionDir, version, nil); err != nil {
+			return err
+		}
+
+		if err := verifyInstalledBinary(binaryDir); err != nil {
+			return err
+		}
+
+		if err := refreshAlias(binaryDir); err != nil {
 			return err
 		}
 
@@ -55,19 +75,23 @@ var setLatestStableCmd = &cobra.Command{
 			return err
 		}
 
-		version, err := getLatestTag()
+		version, err := getLatestTag(versionDir)
 		if err != nil {
 			return err
 		}
 
-		symlinkDir := path.Join(versionDir, "current_version")
-		binaryDir := path.Join(versionDir, version)
+		symlinkDir := filepath.Join(versionDir, "current_version")
+		binaryDir := filepath.Join(versionDir, version)
 
 		if err := checkPathVar(symlinkDir); err != nil {
 			return err
 		}
 
-		if err := downloadSpin(versionDir, version); err != nil {
+		if err := downloadSpin(versionDir, version, nil); err != nil {
+			return err
+		}
+
+		if err := verifyInstalledBinary(binaryDir); err != nil {
 			return err
 		}
 
@@ -80,23 +104,50 @@ var setLatestStableCmd = &cobra.Command{
 	},
 }
 
-// updateSpinBinary creates a symlink pointing to a binary file containing the specified version of Spin
+// updateSpinBinary links "current_version" to the binary of the specified version of Spin (a symlink, or a hard link or
+// copy on Windows). When the version-switching shim is enabled, the version is recorded as the shim's global fallback instead.
 func updateSpinBinary(binaryDir, symlinkDir string) error {
+	lock, err := lockVersionDir()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
 	if err := os.MkdirAll(symlinkDir, 0755); err != nil {
 		return err
 	}
 
-	// If there is already an existing symlink, this deletes the symlink (or deletes nothing if the symlink doesn't exist) so a new one can be created
-	if err := os.Remove(path.Join(symlinkDir, "spin")); err != nil {
-		if !os.IsNotExist(err) {
-			return fmt.Errorf("failed to remove old symlink: %v", err)
-		}
+	enabled, err := shimEnabled(symlinkDir)
+	if err != nil {
+		return err
+	}
+
+	if enabled {
+		return os.WriteFile(filepath.Join(symlinkDir, shimGlobalFileName), []byte(filepath.Base(binaryDir)+"\n"), 0644)
 	}
 
-	if err := os.Symlink(path.Join(binaryDir, "spin"), path.Join(symlinkDir, "spin")); err != nil {
+	// The link replaces the one to the previously set version, if there is one
+	return binaryLinker.Link(filepath.Join(binaryDir, spinBinary), filepath.Join(symlinkDir, spinBinary))
+}
+
+// verifyInstalledBinary re-verifies a Spin binary against the digest recorded when it was installed.
+// Aliases and versions installed before digests were recorded have nothing to verify against and are skipped.
+func verifyInstalledBinary(binaryDir string) error {
+	digestPath := filepath.Join(binaryDir, verman.DigestFileName)
+
+	digestExists, err := exists(digestPath)
+	if err != nil {
 		return err
 	}
 
+	if !digestExists {
+		return nil
+	}
+
+	if err := verman.VerifyDigestFile(binaryDir, digestPath); err != nil {
+		return fmt.Errorf("the installed Spin binary in %q failed verification: %v", binaryDir, err)
+	}
+
 	return nil
 }
 
@@ -106,7 +157,7 @@ func checkPathVar(dirPath string) error {
 	pathSeparator := string(os.PathListSeparator)
 	pathIsInPATH := false
 	for _, p := range strings.Split(path, pathSeparator) {
-		if p == dirPath {
+		if samePath(p, dirPath) {
 			pathIsInPATH = true
 		}
 	}
@@ -116,3 +167,23 @@ func checkPathVar(dirPath string) error {
 
 	return nil
 }
+
+// samePath reports whether a and b refer to the same location, following symlinks so that a $PATH entry under the legacy
+// ~/.spin_verman directory still matches its new location. Paths are compared case-insensitively on Windows.
+func samePath(a, b string) bool {
+	if verman.SamePathName(a, b, runtime.GOOS) {
+		return true
+	}
+
+	resolvedA, err := filepath.EvalSymlinks(a)
+	if err != nil {
+		return false
+	}
+
+	resolvedB, err := filepath.EvalSymlinks(b)
+	if err != nil {
+		return false
+	}
+
+	return verman.SamePathName(resolvedA, resolvedB, runtime.GOOS)
+}
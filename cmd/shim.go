@@ -0,0 +1,293 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/fermyon/verman-plugin/internal/verman"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// shimMarkerFileName indicates that "current_version/spin" is the verman dispatcher rather than a symlink to a Spin binary
+	shimMarkerFileName = ".verman-shim"
+	// shimGlobalFileName records the version of Spin the dispatcher falls back to when no ".spin-version" file is found
+	shimGlobalFileName = ".verman-global"
+)
+
+var shimCmd = &cobra.Command{
+	Use:   "shim",
+	Short: "Manages the shim that automatically switches Spin versions based on \".spin-version\" files.",
+	Long:  "Manages the shim that automatically switches Spin versions based on \".spin-version\" files. When enabled, running \"spin\" looks for a \".spin-version\" file in the working directory and its parents and runs the matching installed version, falling back to the version chosen with \"spin verman set\".",
+}
+
+var shimEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Replaces the current version symlink with the version-switching shim.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		versionDir, err := getVersionDir()
+		if err != nil {
+			return err
+		}
+
+		symlinkDir := filepath.Join(versionDir, "current_version")
+
+		if err := checkPathVar(symlinkDir); err != nil {
+			return err
+		}
+
+		if err := enableShim(versionDir, symlinkDir); err != nil {
+			return err
+		}
+
+		fmt.Println("The Spin version shim has been enabled")
+		return nil
+	},
+}
+
+var shimDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Replaces the version-switching shim with a symlink to the version chosen with \"spin verman set\".",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		versionDir, err := getVersionDir()
+		if err != nil {
+			return err
+		}
+
+		if err := disableShim(versionDir, filepath.Join(versionDir, "current_version")); err != nil {
+			return err
+		}
+
+		fmt.Println("The Spin version shim has been disabled")
+		return nil
+	},
+}
+
+// isShimInvocation indicates whether this binary was run through the "spin" shim rather than as the verman plugin
+func isShimInvocation() bool {
+	return strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe") == "spin"
+}
+
+// shimEnabled indicates whether the version-switching shim is installed in the given "current_version" directory
+func shimEnabled(symlinkDir string) (bool, error) {
+	return exists(filepath.Join(symlinkDir, shimMarkerFileName))
+}
+
+// enableShim points "current_version/spin" at this executable, preserving the currently set version as the global fallback
+func enableShim(versionDir, symlinkDir string) error {
+	lock, err := lockVersionDir()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	enabled, err := shimEnabled(symlinkDir)
+	if err != nil {
+		return err
+	}
+
+	if enabled {
+		return nil
+	}
+
+	if err := os.MkdirAll(symlinkDir, 0755); err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	executable, err = filepath.EvalSymlinks(executable)
+	if err != nil {
+		return err
+	}
+
+	// The version currently set becomes the global fallback
+	spinLink := filepath.Join(symlinkDir, spinBinary)
+	if target, err := binaryLinker.Target(spinLink); err == nil && samePath(filepath.Dir(filepath.Dir(target)), versionDir) {
+		if err := os.WriteFile(filepath.Join(symlinkDir, shimGlobalFileName), []byte(filepath.Base(filepath.Dir(target))+"\n"), 0644); err != nil {
+			return err
+		}
+	}
+
+	if err := binaryLinker.Link(executable, spinLink); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(symlinkDir, shimMarkerFileName), []byte(executable+"\n"), 0644)
+}
+
+// disableShim restores "current_version/spin" to a symlink to the global fallback version, if there is one
+func disableShim(versionDir, symlinkDir string) error {
+	global, err := removeShim(symlinkDir)
+	if err != nil || global == "" {
+		return err
+	}
+
+	return updateSpinBinary(filepath.Join(versionDir, global), symlinkDir)
+}
+
+// removeShim removes the shim and its state from the "current_version" directory, returning its global fallback version
+func removeShim(symlinkDir string) (string, error) {
+	lock, err := lockVersionDir()
+	if err != nil {
+		return "", err
+	}
+	defer lock.Release()
+
+	enabled, err := shimEnabled(symlinkDir)
+	if err != nil || !enabled {
+		return "", err
+	}
+
+	global, err := readShimGlobal(symlinkDir)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.Remove(filepath.Join(symlinkDir, shimMarkerFileName)); err != nil {
+		return "", err
+	}
+
+	if err := binaryLinker.Remove(filepath.Join(symlinkDir, spinBinary)); err != nil {
+		return "", fmt.Errorf("failed to remove shim: %v", err)
+	}
+
+	if err := os.Remove(filepath.Join(symlinkDir, shimGlobalFileName)); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	return global, nil
+}
+
+// readShimGlobal returns the global fallback version used by the shim, or an empty string if none has been set
+func readShimGlobal(symlinkDir string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(symlinkDir, shimGlobalFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+
+		return "", err
+	}
+
+	return strings.TrimSpace(string(content)), nil
+}
+
+// runShim dispatches a "spin" invocation to the version requested by the project (see verman.FindVersionSource), the global
+// version chosen with "spin verman set", or otherwise the next Spin binary in $PATH
+func runShim(args []string) error {
+	versionDir, err := getVersionDir()
+	if err != nil {
+		return err
+	}
+
+	symlinkDir := filepath.Join(versionDir, "current_version")
+
+	binaryPath, err := findShimTarget(versionDir, symlinkDir)
+	if err != nil {
+		return err
+	}
+
+	// A stale alias still runs, as the binary it was copied from may be mid-rebuild
+	if err := refreshAlias(filepath.Dir(binaryPath)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	return execSpin(binaryPath, args)
+}
+
+// findShimTarget returns the path of the Spin binary that the shim should run
+func findShimTarget(versionDir, symlinkDir string) (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	source, err := verman.FindVersionSource(cwd)
+	if err != nil {
+		return "", err
+	}
+
+	if source != nil {
+		if binaryPath := findInstalledBinary(versionDir, source.Version); binaryPath != "" {
+			return binaryPath, nil
+		}
+
+		// Falling back rather than failing keeps "spin verman get" usable for installing the missing version
+		fmt.Fprintf(os.Stderr, "Warning: Spin version %q requested by %s is not installed; run \"spin verman get\" to install it\n", source.Version, source.Path)
+	}
+
+	global, err := readShimGlobal(symlinkDir)
+	if err != nil {
+		return "", err
+	}
+
+	if global != "" {
+		if binaryPath := findInstalledBinary(versionDir, global); binaryPath != "" {
+			return binaryPath, nil
+		}
+	}
+
+	return findSpinInPath(symlinkDir)
+}
+
+// findInstalledBinary returns the path of the installed Spin binary matching the requested version or constraint,
+// or an empty string if there is none. Constraints are resolved against the installed versions only.
+func findInstalledBinary(versionDir, requested string) string {
+	candidates := []string{requested}
+	if !strings.HasPrefix(requested, "v") {
+		candidates = append(candidates, "v"+requested)
+	}
+
+	if verman.IsVersionConstraint(requested) {
+		if installed, err := verman.NewRepository(versionDir).Names(); err == nil {
+			if resolved, err := verman.ResolveVersion(requested, installed); err == nil {
+				candidates = append(candidates, resolved)
+			}
+		}
+	}
+
+	for _, candidate := range candidates {
+		binaryPath := filepath.Join(versionDir, candidate, spinBinary)
+		if info, err := os.Stat(binaryPath); err == nil && !info.IsDir() {
+			return binaryPath
+		}
+	}
+
+	return ""
+}
+
+// findSpinInPath returns the first Spin binary in $PATH that isn't the shim itself
+func findSpinInPath(symlinkDir string) (string, error) {
+	executable, err := os.Executable()
+	if err == nil {
+		executable, _ = filepath.EvalSymlinks(executable)
+	}
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" || filepath.Clean(dir) == filepath.Clean(symlinkDir) {
+			continue
+		}
+
+		candidate := filepath.Join(dir, spinBinary)
+		info, err := os.Stat(candidate)
+		// Windows has no executable permission
+		if err != nil || info.IsDir() || runtime.GOOS != "windows" && info.Mode()&0111 == 0 {
+			continue
+		}
+
+		if resolved, err := filepath.EvalSymlinks(candidate); err == nil && resolved == executable {
+			continue
+		}
+
+		return candidate, nil
+	}
+
+	return "", fmt.Errorf("no version of Spin has been set; run \"spin verman set\" to choose one")
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fermyon/verman-plugin/internal/verman"
+)
+
+var (
+	// httpTimeout and httpRetries configure the client used for every request verman makes
+	httpTimeout time.Duration
+	httpRetries int
+
+	httpClientOnce sync.Once
+	httpClient     *http.Client
+	httpClientErr  error
+
+	configOnce   sync.Once
+	loadedConfig *verman.Config
+	configErr    error
+
+	sourceOnce    sync.Once
+	releaseSource verman.ReleaseSource
+	sourceErr     error
+)
+
+// getHTTPClient returns the client used for every request verman makes, which connects through the configured proxy
+// and CAs, authenticates requests to GitHub and retries failed requests
+func getHTTPClient() (*http.Client, error) {
+	httpClientOnce.Do(func() {
+		config, err := getConfig()
+		if err != nil {
+			httpClientErr = err
+			return
+		}
+
+		httpClient, httpClientErr = verman.NewHTTPClient(verman.HTTPClientOptions{
+			Timeout:     httpTimeout,
+			Retries:     httpRetries,
+			HTTP:        verman.HTTPConfigFromEnv(config.HTTP),
+			GitHubToken: verman.GitHubToken,
+			OnRetry: func(req *http.Request, err error, delay time.Duration) {
+				fmt.Fprintf(os.Stderr, "Request to %s failed (%v); retrying in %s...\n", req.URL.Redacted(), err, delay.Round(100*time.Millisecond))
+			},
+		})
+	})
+
+	return httpClient, httpClientErr
+}
+
+// getConfig returns the configuration from the config directory
+func getConfig() (*verman.Config, error) {
+	configOnce.Do(func() {
+		dirs, err := getDirs()
+		if err != nil {
+			configErr = err
+			return
+		}
+
+		loadedConfig, configErr = verman.LoadConfig(filepath.Join(dirs.Config, verman.ConfigFileName))
+	})
+
+	return loadedConfig, configErr
+}
+
+// getReleaseSource returns the source Spin releases are listed and downloaded from, which is selected by
+// $SPIN_VERMAN_MIRROR or the configuration file and defaults to GitHub
+func getReleaseSource() (verman.ReleaseSource, error) {
+	sourceOnce.Do(func() {
+		config, err := getConfig()
+		if err != nil {
+			sourceErr = err
+			return
+		}
+
+		client, err := getHTTPClient()
+		if err != nil {
+			sourceErr = err
+			return
+		}
+
+		releaseSource, sourceErr = verman.NewReleaseSource(config.ReleaseSource, os.Getenv(verman.MirrorEnvVar), client)
+	})
+
+	return releaseSource, sourceErr
+}
@@ -1,13 +1,20 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"path"
+	"os/exec"
+	"path/filepath"
+	"time"
 
+	"github.com/fermyon/verman-plugin/internal/verman"
 	"github.com/spf13/cobra"
 )
 
+// canaryKeep is how many previous canary builds "update canary" keeps, overriding the configuration file
+var canaryKeep int
+
 var updateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "Updates the binary files found locally for the requested versions of Spin. Currently only supports the \"canary\" subcommand.",
@@ -16,32 +23,194 @@ var updateCmd = &cobra.Command{
 var updateCanaryCmd = &cobra.Command{
 	Use:   "canary",
 	Short: "Updates the binary files found locally for the canary version of Spin. If the canary version is not found locally, it will be retrieved from source.",
+	Long:  "Updates the binary files found locally for the canary version of Spin. If the canary version is not found locally, it will be retrieved from source. The previous canary build is kept as \"canary@<date>-<commit>\", so it can be restored with \"spin verman set canary@previous\".",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		versionDir, err := getVersionDir()
 		if err != nil {
 			return err
 		}
 
-		_, canaryFileErr := os.Stat(path.Join(versionDir, "canary"))
-		if err != nil {
-			if !os.IsNotExist(canaryFileErr) {
-				return canaryFileErr
+		keep := canaryKeep
+		if !cmd.Flags().Changed("keep") {
+			config, err := getConfig()
+			if err != nil {
+				return err
 			}
+			keep = config.Canary.KeepSnapshots()
 		}
 
-		if err := remove("canary"); err != nil {
+		repository := verman.NewRepository(versionDir)
+
+		previous, err := repository.Get("canary")
+		if err != nil {
 			return err
 		}
 
-		// If the canary file already existed locally...
-		if !os.IsNotExist(canaryFileErr) {
+		snapshot, snapshotCreated := "", false
+		if previous != nil && keep > 0 {
+			snapshot, snapshotCreated, err = snapshotCanary(versionDir, previous)
+			if err != nil {
+				return err
+			}
+		} else if previous != nil {
+			if err := remove("canary"); err != nil {
+				return err
+			}
+
 			fmt.Println("Old canary version successfully deleted")
 		}
 
-		if err := downloadSpin(versionDir, "canary"); err != nil {
+		if err := downloadSpin(versionDir, "canary", nil); err != nil {
+			// The previous canary build is put back, rather than leaving no canary at all
+			if snapshot != "" {
+				if restoreErr := restoreCanary(versionDir, snapshot); restoreErr != nil {
+					return fmt.Errorf("%v (and the previous canary build couldn't be restored from %s: %v)", err, snapshot, restoreErr)
+				}
+			}
 			return err
 		}
 
-		return nil
+		if snapshot != "" {
+			current, err := repository.Get("canary")
+			if err != nil {
+				return err
+			}
+
+			kept, err := repository.Get(snapshot)
+			if err != nil {
+				return err
+			}
+
+			if current != nil && kept != nil && verman.SameCanaryBuild(current.Metadata, kept.Metadata) {
+				if current.Metadata.Commit != "" {
+					fmt.Printf("Canary is unchanged at commit %s\n", current.Metadata.Commit)
+				} else {
+					fmt.Println("Canary is unchanged")
+				}
+				// A snapshot that was already kept before this update stays
+				if snapshotCreated {
+					if err := remove(snapshot); err != nil {
+						return err
+					}
+				}
+			} else {
+				fmt.Printf("The previous canary build was kept as %s\n", snapshot)
+			}
+		}
+
+		return pruneCanarySnapshots(versionDir, keep)
 	},
 }
+
+// snapshotCanary renames the installed canary build to its snapshot name, returning that name and whether the snapshot
+// was created. A build that is already kept under that name is removed instead, and the existing snapshot returned, so
+// that it can still be restored if the update fails.
+func snapshotCanary(versionDir string, canary *verman.InstalledVersion) (string, bool, error) {
+	metadata := *canary.Metadata
+
+	// Canary builds installed before commits were recorded are identified by running them
+	if metadata.Commit == "" {
+		metadata.Commit, metadata.BuildDate = spinBuildInfo(canary.BinaryPath())
+	}
+	if metadata.SHA256 == "" {
+		metadata.SHA256, _ = verman.FileSHA256(canary.BinaryPath())
+	}
+
+	snapshot := verman.CanarySnapshotName(&metadata)
+
+	lock, err := lockVersionDir()
+	if err != nil {
+		return "", false, err
+	}
+	defer lock.Release()
+
+	snapshotDir := filepath.Join(versionDir, snapshot)
+
+	snapshotExists, err := exists(snapshotDir)
+	if err != nil {
+		return "", false, err
+	}
+
+	if snapshotExists {
+		return snapshot, false, os.RemoveAll(canary.Dir)
+	}
+
+	if err := os.Rename(canary.Dir, snapshotDir); err != nil {
+		return "", false, err
+	}
+
+	metadata.Version = snapshot
+	metadata.Inferred = false
+
+	return snapshot, true, verman.WriteMetadata(snapshotDir, &metadata)
+}
+
+// restoreCanary renames a snapshot back to "canary"
+func restoreCanary(versionDir, snapshot string) error {
+	lock, err := lockVersionDir()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	canaryDir := filepath.Join(versionDir, "canary")
+
+	metadata, err := verman.ReadMetadata(filepath.Join(versionDir, snapshot))
+	if err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(canaryDir); err != nil {
+		return err
+	}
+
+	if err := os.Rename(filepath.Join(versionDir, snapshot), canaryDir); err != nil {
+		return err
+	}
+
+	if metadata == nil {
+		return nil
+	}
+
+	metadata.Version = "canary"
+	return verman.WriteMetadata(canaryDir, metadata)
+}
+
+// pruneCanarySnapshots removes all but the most recent keep snapshots. The snapshot that is currently set is kept too.
+func pruneCanarySnapshots(versionDir string, keep int) error {
+	snapshots, err := verman.NewRepository(versionDir).CanarySnapshots()
+	if err != nil {
+		return err
+	}
+
+	current := currentVersion(versionDir)
+
+	for i, snapshot := range snapshots {
+		if i < keep || snapshot.Name == current {
+			continue
+		}
+
+		if err := remove(snapshot.Name); err != nil {
+			return err
+		}
+
+		fmt.Printf("Removed old canary build %s\n", snapshot.Name)
+	}
+
+	return nil
+}
+
+// spinBuildInfo returns the commit and build date reported by a Spin binary's "spin --version", or empty strings if it
+// can't be run or doesn't report them
+func spinBuildInfo(binaryPath string) (string, string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, binaryPath, "--version").Output()
+	if err != nil {
+		return "", ""
+	}
+
+	commit, buildDate, _ := verman.ParseSpinVersion(string(output))
+	return commit, buildDate
+}
@@ -2,10 +2,13 @@ module github.com/fermyon/verman-plugin
 
 go 1.22.4
 
-require github.com/spf13/cobra v1.8.1
+require (
+	github.com/BurntSushi/toml v1.4.0
+	github.com/spf13/cobra v1.8.1
+	golang.org/x/mod v0.21.0
+)
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
-	golang.org/x/mod v0.21.0 // indirect
 )
@@ -0,0 +1,124 @@
+package verman
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExtractFiles extracts the named regular files from the top level of a release archive into destDir, preserving their
+// permissions, and returns how many of them were found. Archives ending in ".zip" (as released for Windows) are read
+// as zip files, and everything else as .tar.gz files.
+func ExtractFiles(archivePath, destDir string, names ...string) (int, error) {
+	wanted := map[string]bool{}
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	if strings.HasSuffix(archivePath, ".zip") {
+		return extractFromZip(archivePath, destDir, wanted)
+	}
+
+	return extractFromTarGz(archivePath, destDir, wanted)
+}
+
+func extractFromTarGz(tarGzPath, destDir string, wanted map[string]bool) (int, error) {
+	gzipFile, err := os.Open(tarGzPath)
+	if err != nil {
+		return 0, err
+	}
+	defer gzipFile.Close()
+
+	uncompressedStream, err := gzip.NewReader(gzipFile)
+	if err != nil {
+		return 0, err
+	}
+
+	tarReader := tar.NewReader(uncompressedStream)
+	found := 0
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return found, fmt.Errorf("extractFromTarGz: Next() failed: %w", err)
+		}
+
+		if header.Typeflag != tar.TypeReg || !wanted[header.Name] {
+			continue
+		}
+
+		if err := writeExtractedFile(filepath.Join(destDir, header.Name), tarReader, os.FileMode(header.Mode)); err != nil {
+			return found, err
+		}
+
+		found++
+	}
+
+	return found, nil
+}
+
+func extractFromZip(zipPath, destDir string, wanted map[string]bool) (int, error) {
+	zipReader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return 0, err
+	}
+	defer zipReader.Close()
+
+	found := 0
+
+	for _, file := range zipReader.File {
+		if !file.Mode().IsRegular() || !wanted[file.Name] {
+			continue
+		}
+
+		content, err := file.Open()
+		if err != nil {
+			return found, err
+		}
+
+		// Zip files created on Windows have no executable permission, which the binary needs elsewhere
+		err = writeExtractedFile(filepath.Join(destDir, file.Name), content, file.Mode().Perm()|0755)
+		content.Close()
+		if err != nil {
+			return found, err
+		}
+
+		found++
+	}
+
+	return found, nil
+}
+
+// writeExtractedFile writes the content of an archive entry to outPath with the given permissions
+func writeExtractedFile(outPath string, content io.Reader, mode os.FileMode) error {
+	// Create the file with the original permissions
+	outFile, err := os.OpenFile(outPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(outFile, content); err != nil {
+		outFile.Close()
+		return err
+	}
+
+	if err := outFile.Close(); err != nil {
+		return err
+	}
+
+	// Ensure the file has the correct permissions, as the umask applies when it is created
+	if err := os.Chmod(outPath, mode); err != nil {
+		return fmt.Errorf("could not set file permissions: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package verman
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// archiveEntry is a file in a test archive
+type archiveEntry struct {
+	name    string
+	content string
+	mode    os.FileMode
+}
+
+func TestExtractFiles(t *testing.T) {
+	entries := []archiveEntry{
+		{name: "README.md", content: "readme", mode: 0644},
+		{name: "spin", content: "binary", mode: 0755},
+		{name: "spin.exe", content: "windows binary", mode: 0644},
+		{name: "nested/spin", content: "nested", mode: 0755},
+	}
+
+	tests := []struct {
+		name          string
+		archiveName   string
+		write         func(t *testing.T, archivePath string, entries []archiveEntry)
+		wanted        []string
+		expectedFound int
+		expectedMode  os.FileMode
+	}{
+		{name: "tar.gz", archiveName: "spin-v2.7.0-linux-amd64.tar.gz", write: writeTarGz, wanted: []string{"spin", "spin.sig"}, expectedFound: 1, expectedMode: 0755},
+		{name: "zip", archiveName: "spin-v2.7.0-windows-amd64.zip", write: writeZip, wanted: []string{"spin.exe"}, expectedFound: 1, expectedMode: 0755},
+		{name: "Several files", archiveName: "spin.tar.gz", write: writeTarGz, wanted: []string{"spin", "README.md"}, expectedFound: 2, expectedMode: 0755},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			archivePath := filepath.Join(dir, tt.archiveName)
+			tt.write(t, archivePath, entries)
+
+			destDir := filepath.Join(dir, "out")
+			if err := os.Mkdir(destDir, 0755); err != nil {
+				t.Fatalf("failed to create directory: %v", err)
+			}
+
+			found, err := ExtractFiles(archivePath, destDir, tt.wanted...)
+			if err != nil {
+				t.Fatalf("failed to extract files: %v", err)
+			}
+
+			if found != tt.expectedFound {
+				t.Errorf("expected %d files, got: %d", tt.expectedFound, found)
+			}
+
+			info, err := os.Stat(filepath.Join(destDir, tt.wanted[0]))
+			if err != nil {
+				t.Fatalf("expected %s to be extracted: %v", tt.wanted[0], err)
+			}
+
+			if info.Mode().Perm() != tt.expectedMode {
+				t.Errorf("expected mode %v, got: %v", tt.expectedMode, info.Mode().Perm())
+			}
+
+			// Only files at the top level of the archive are extracted
+			if _, err := os.Stat(filepath.Join(destDir, "nested")); !os.IsNotExist(err) {
+				t.Errorf("expected nested files not to be extracted")
+			}
+		})
+	}
+}
+
+func writeTarGz(t *testing.T, archivePath string, entries []archiveEntry) {
+	t.Helper()
+
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+	defer file.Close()
+
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: int64(entry.mode), Size: int64(len(entry.content)), Typeflag: tar.TypeReg}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			t.Fatalf("failed to write archive: %v", err)
+		}
+		if _, err := tarWriter.Write([]byte(entry.content)); err != nil {
+			t.Fatalf("failed to write archive: %v", err)
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+}
+
+func writeZip(t *testing.T, archivePath string, entries []archiveEntry) {
+	t.Helper()
+
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+	defer file.Close()
+
+	zipWriter := zip.NewWriter(file)
+
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		header.SetMode(entry.mode)
+
+		writer, err := zipWriter.CreateHeader(header)
+		if err != nil {
+			t.Fatalf("failed to write archive: %v", err)
+		}
+		if _, err := writer.Write([]byte(entry.content)); err != nil {
+			t.Fatalf("failed to write archive: %v", err)
+		}
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+}
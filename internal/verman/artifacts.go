@@ -0,0 +1,359 @@
+package verman
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// The prefixes of the versions "get" installs from GitHub Actions artifacts: "pr/<number>" and "commit/<sha>"
+	ArtifactPRPrefix     = "pr/"
+	ArtifactCommitPrefix = "commit/"
+
+	githubArtifactsPerPage = 100
+)
+
+var (
+	commitSHAPattern    = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+	artifactNameTokenRe = regexp.MustCompile(`[^a-z0-9_]+`)
+)
+
+// artifactOSTokens and artifactArchTokens are the words artifact names use for each Go OS and architecture, e.g. the
+// runner image ("ubuntu-latest") or Rust target ("x86_64-unknown-linux-musl") they were built on or for
+var (
+	artifactOSTokens = map[string][]string{
+		"linux":   {"linux", "ubuntu"},
+		"darwin":  {"macos", "darwin", "osx", "apple"},
+		"windows": {"windows", "win"},
+	}
+	artifactArchTokens = map[string][]string{
+		"amd64": {"amd64", "x86_64", "x64"},
+		"arm64": {"arm64", "aarch64"},
+	}
+	artifactStaticTokens = []string{"static", "musl"}
+)
+
+// ArtifactRef is a pull request or commit of Spin whose CI build is installed from GitHub Actions artifacts
+type ArtifactRef struct {
+	PR     int
+	Commit string
+}
+
+// ParseArtifactRef parses "pr/<number>" or "commit/<sha>". ok is false if spec is neither, e.g. a release version.
+func ParseArtifactRef(spec string) (ref ArtifactRef, ok bool, err error) {
+	switch {
+	case strings.HasPrefix(spec, ArtifactPRPrefix):
+		number, err := strconv.Atoi(strings.TrimPrefix(spec, ArtifactPRPrefix))
+		if err != nil || number <= 0 {
+			return ref, true, fmt.Errorf("invalid pull request %q: expected pr/<number>", spec)
+		}
+		return ArtifactRef{PR: number}, true, nil
+	case strings.HasPrefix(spec, ArtifactCommitPrefix):
+		sha := strings.TrimPrefix(spec, ArtifactCommitPrefix)
+		if !commitSHAPattern.MatchString(sha) {
+			return ref, true, fmt.Errorf("invalid commit %q: expected commit/<sha> with at least %d hex digits", spec, shortCommitLength)
+		}
+		return ArtifactRef{Commit: strings.ToLower(sha)}, true, nil
+	default:
+		return ref, false, nil
+	}
+}
+
+func (r ArtifactRef) String() string {
+	if r.PR > 0 {
+		return ArtifactPRPrefix + strconv.Itoa(r.PR)
+	}
+	return ArtifactCommitPrefix + r.Commit
+}
+
+// VersionName returns the name the build is installed as: "pr-<number>", or "commit-<short sha>"
+func (r ArtifactRef) VersionName() string {
+	if r.PR > 0 {
+		return "pr-" + strconv.Itoa(r.PR)
+	}
+	return "commit-" + r.Commit[:shortCommitLength]
+}
+
+// WorkflowRun is a successful GitHub Actions workflow run
+type WorkflowRun struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	HTMLURL string `json:"html_url"`
+	HeadSHA string `json:"head_sha"`
+}
+
+// Artifact is a file uploaded by a GitHub Actions workflow run
+type Artifact struct {
+	ID                 int64  `json:"id"`
+	Name               string `json:"name"`
+	SizeInBytes        int64  `json:"size_in_bytes"`
+	ArchiveDownloadURL string `json:"archive_download_url"`
+	Expired            bool   `json:"expired"`
+	Digest             string `json:"digest,omitempty"` // e.g. "sha256:<hex>", only set for recently uploaded artifacts
+}
+
+// ArtifactSource finds the GitHub Actions artifacts of pull requests and commits of a GitHub repository. Listing and
+// downloading artifacts requires the client to authenticate (see NewHTTPClient).
+type ArtifactSource struct {
+	Client     *http.Client
+	Repository string
+	APIURL     string // e.g. "https://api.github.com"
+}
+
+// NewArtifactSource returns the source of the artifacts of a GitHub repository, e.g. "fermyon/spin"
+func NewArtifactSource(client *http.Client, repository string) *ArtifactSource {
+	if repository == "" {
+		repository = DefaultGitHubRepository
+	}
+
+	return &ArtifactSource{Client: client, Repository: repository, APIURL: githubAPIURL}
+}
+
+// ResolveCommit returns the full SHA of a commit, or of the head commit of a pull request
+func (s *ArtifactSource) ResolveCommit(ref ArtifactRef) (string, error) {
+	if ref.PR > 0 {
+		var pull struct {
+			Head struct {
+				SHA string `json:"sha"`
+			} `json:"head"`
+		}
+		if err := s.getJSON(fmt.Sprintf("/repos/%s/pulls/%d", s.Repository, ref.PR), ref.String(), &pull); err != nil {
+			return "", err
+		}
+		return pull.Head.SHA, nil
+	}
+
+	var commit struct {
+		SHA string `json:"sha"`
+	}
+	if err := s.getJSON(fmt.Sprintf("/repos/%s/commits/%s", s.Repository, ref.Commit), ref.String(), &commit); err != nil {
+		return "", err
+	}
+	return commit.SHA, nil
+}
+
+// FindArtifact returns the newest successful workflow run for commit that uploaded an artifact of Spin for a platform,
+// and that artifact. If name is set, the artifact with that name is used instead of matching on its name.
+func (s *ArtifactSource) FindArtifact(commit, goos, goarch, variant, name string) (*WorkflowRun, *Artifact, error) {
+	var runs struct {
+		WorkflowRuns []WorkflowRun `json:"workflow_runs"`
+	}
+	query := url.Values{"head_sha": {commit}, "status": {"success"}, "per_page": {strconv.Itoa(githubArtifactsPerPage)}}
+	if err := s.getJSON(fmt.Sprintf("/repos/%s/actions/runs?%s", s.Repository, query.Encode()), "workflow runs", &runs); err != nil {
+		return nil, nil, err
+	}
+
+	if len(runs.WorkflowRuns) == 0 {
+		return nil, nil, fmt.Errorf("no successful workflow runs were found for commit %s of %s", commit, s.Repository)
+	}
+
+	var seen []string
+	for i := range runs.WorkflowRuns {
+		run := &runs.WorkflowRuns[i]
+
+		var page struct {
+			Artifacts []Artifact `json:"artifacts"`
+		}
+		if err := s.getJSON(fmt.Sprintf("/repos/%s/actions/runs/%d/artifacts?per_page=%d", s.Repository, run.ID, githubArtifactsPerPage), "artifacts", &page); err != nil {
+			return nil, nil, err
+		}
+
+		var available []Artifact
+		for _, artifact := range page.Artifacts {
+			if !artifact.Expired {
+				available = append(available, artifact)
+				seen = append(seen, artifact.Name)
+			}
+		}
+
+		var artifact *Artifact
+		var err error
+		if name != "" {
+			for i := range available {
+				if available[i].Name == name {
+					artifact = &available[i]
+				}
+			}
+		} else {
+			artifact, err = SelectArtifact(available, goos, goarch, variant)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%v in %s", err, run.HTMLURL)
+		}
+
+		if artifact != nil {
+			return run, artifact, nil
+		}
+	}
+
+	if len(seen) == 0 {
+		return nil, nil, fmt.Errorf("the workflow runs for commit %s have no artifacts (artifacts expire after a while)", commit)
+	}
+
+	target := name
+	if target == "" {
+		target = "an artifact for " + goos + "/" + goarch
+	}
+	return nil, nil, fmt.Errorf("%s was not found for commit %s; the available artifacts are: %s", target, commit, strings.Join(seen, ", "))
+}
+
+// SelectArtifact returns the artifact whose name identifies it as a build of Spin for a Go OS, architecture and
+// variant, or nil if none does. An artifact that names the architecture is preferred over one that only names the OS.
+// Several equally good matches are an error, as the right one can't be told apart.
+func SelectArtifact(artifacts []Artifact, goos, goarch, variant string) (*Artifact, error) {
+	var exact, osOnly []*Artifact
+
+	for i := range artifacts {
+		tokens := map[string]bool{}
+		for _, token := range artifactNameTokenRe.Split(strings.ToLower(artifacts[i].Name), -1) {
+			tokens[token] = true
+		}
+
+		if !hasAnyToken(tokens, artifactOSTokens[goos]) || hasAnyToken(tokens, artifactStaticTokens) != (variant == VariantStatic) {
+			continue
+		}
+
+		matchesArch := hasAnyToken(tokens, artifactArchTokens[goarch])
+		namesArch := matchesArch
+		for arch, archTokens := range artifactArchTokens {
+			if arch != goarch && hasAnyToken(tokens, archTokens) {
+				namesArch = true
+			}
+		}
+
+		switch {
+		case matchesArch:
+			exact = append(exact, &artifacts[i])
+		case !namesArch:
+			osOnly = append(osOnly, &artifacts[i])
+		}
+	}
+
+	for _, candidates := range [][]*Artifact{exact, osOnly} {
+		switch len(candidates) {
+		case 0:
+			continue
+		case 1:
+			return candidates[0], nil
+		default:
+			var names []string
+			for _, candidate := range candidates {
+				names = append(names, candidate.Name)
+			}
+			return nil, fmt.Errorf("several artifacts match %s/%s (%s); choose one with --artifact", goos, goarch, strings.Join(names, ", "))
+		}
+	}
+
+	return nil, nil
+}
+
+func hasAnyToken(tokens map[string]bool, wanted []string) bool {
+	for _, token := range wanted {
+		if tokens[token] {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ArtifactSource) getJSON(apiPath, what string, v any) error {
+	resp, err := s.Client.Get(s.APIURL + apiPath)
+	if err != nil {
+		return fmt.Errorf("failed to load %s from GitHub: %v", what, err)
+	}
+
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return fmt.Errorf("%s was not found in %s", what, s.Repository)
+	default:
+		return StatusError(resp, fmt.Sprintf("failed to load %s from GitHub", what))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %v", what, err)
+	}
+
+	return nil
+}
+
+// ExtractArtifactBinary extracts the Spin binary from a downloaded artifact into destDir. Artifacts are zip files that
+// contain either the binary itself (possibly in a directory) or a release archive containing it.
+func ExtractArtifactBinary(artifactPath, destDir, binaryName string) error {
+	zipReader, err := zip.OpenReader(artifactPath)
+	if err != nil {
+		return fmt.Errorf("invalid artifact: %v", err)
+	}
+	defer zipReader.Close()
+
+	var archives []*zip.File
+	for _, file := range zipReader.File {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+
+		base := path.Base(file.Name)
+		if base == binaryName {
+			content, err := file.Open()
+			if err != nil {
+				return err
+			}
+			defer content.Close()
+
+			// Artifacts don't preserve permissions, so the binary is made executable
+			return writeExtractedFile(filepath.Join(destDir, binaryName), content, 0755)
+		}
+
+		if strings.HasSuffix(base, ".tar.gz") || strings.HasSuffix(base, ".tgz") || strings.HasSuffix(base, ".zip") {
+			archives = append(archives, file)
+		}
+	}
+
+	for _, file := range archives {
+		found, err := extractNestedArchive(file, destDir, binaryName)
+		if err != nil || found {
+			return err
+		}
+	}
+
+	return fmt.Errorf("the artifact doesn't contain %s", binaryName)
+}
+
+// extractNestedArchive extracts binaryName from a release archive in an artifact
+func extractNestedArchive(file *zip.File, destDir, binaryName string) (bool, error) {
+	content, err := file.Open()
+	if err != nil {
+		return false, err
+	}
+	defer content.Close()
+
+	// ExtractFiles tells zip and tar.gz archives apart by their extension
+	archive, err := os.CreateTemp(destDir, ".artifact-*-"+path.Base(file.Name))
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(archive.Name())
+
+	_, err = io.Copy(archive, content)
+	if closeErr := archive.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return false, err
+	}
+
+	found, err := ExtractFiles(archive.Name(), destDir, binaryName)
+	return found > 0, err
+}
@@ -0,0 +1,234 @@
+package verman
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseArtifactRef(t *testing.T) {
+	tests := []struct {
+		spec         string
+		expectedOK   bool
+		expectError  bool
+		expectedName string
+	}{
+		{spec: "pr/2890", expectedOK: true, expectedName: "pr-2890"},
+		{spec: "commit/1A2B3C4D5E", expectedOK: true, expectedName: "commit-1a2b3c4"},
+		{spec: "commit/1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b", expectedOK: true, expectedName: "commit-1a2b3c4"},
+		{spec: "pr/abc", expectedOK: true, expectError: true},
+		{spec: "pr/0", expectedOK: true, expectError: true},
+		{spec: "commit/1a2b", expectedOK: true, expectError: true},
+		{spec: "commit/main", expectedOK: true, expectError: true},
+		{spec: "2.7.0"},
+		{spec: "canary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			ref, ok, err := ParseArtifactRef(tt.spec)
+			if ok != tt.expectedOK || (err != nil) != tt.expectError {
+				t.Fatalf("expected ok: %v and error: %v, got: %v and %v", tt.expectedOK, tt.expectError, ok, err)
+			}
+
+			if tt.expectedName != "" && ref.VersionName() != tt.expectedName {
+				t.Errorf("expected version name: %s, got: %s", tt.expectedName, ref.VersionName())
+			}
+		})
+	}
+}
+
+func TestSelectArtifact(t *testing.T) {
+	artifacts := func(names ...string) []Artifact {
+		var artifacts []Artifact
+		for _, name := range names {
+			artifacts = append(artifacts, Artifact{Name: name})
+		}
+		return artifacts
+	}
+
+	tests := []struct {
+		name        string
+		artifacts   []Artifact
+		goos        string
+		goarch      string
+		variant     string
+		expected    string
+		expectError bool
+	}{
+		{name: "Runner names", artifacts: artifacts("spin-ubuntu-latest", "spin-macos-latest", "spin-windows-latest"), goos: "darwin", goarch: "arm64", expected: "spin-macos-latest"},
+		{name: "Rust targets", artifacts: artifacts("spin-x86_64-unknown-linux-gnu", "spin-aarch64-unknown-linux-gnu", "spin-x86_64-unknown-linux-musl"), goos: "linux", goarch: "arm64", variant: VariantGlibc, expected: "spin-aarch64-unknown-linux-gnu"},
+		{name: "Static", artifacts: artifacts("spin-x86_64-unknown-linux-gnu", "spin-x86_64-unknown-linux-musl"), goos: "linux", goarch: "amd64", variant: VariantStatic, expected: "spin-x86_64-unknown-linux-musl"},
+		{name: "Architecture preferred", artifacts: artifacts("spin-linux", "spin-linux-amd64"), goos: "linux", goarch: "amd64", variant: VariantGlibc, expected: "spin-linux-amd64"},
+		{name: "Other architecture", artifacts: artifacts("spin-linux-aarch64"), goos: "linux", goarch: "amd64", variant: VariantGlibc},
+		{name: "No match", artifacts: artifacts("test-results", "coverage"), goos: "linux", goarch: "amd64", variant: VariantGlibc},
+		{name: "Ambiguous", artifacts: artifacts("spin-ubuntu-latest", "spin-ubuntu-22.04"), goos: "linux", goarch: "amd64", variant: VariantGlibc, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			artifact, err := SelectArtifact(tt.artifacts, tt.goos, tt.goarch, tt.variant)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error: %v, got: %v", tt.expectError, err)
+			}
+
+			name := ""
+			if artifact != nil {
+				name = artifact.Name
+			}
+			if name != tt.expected {
+				t.Errorf("expected artifact: %q, got: %q", tt.expected, name)
+			}
+		})
+	}
+}
+
+func TestExtractArtifactBinary(t *testing.T) {
+	tests := []struct {
+		name        string
+		write       func(t *testing.T, artifactPath string)
+		expected    string
+		expectError bool
+	}{
+		{
+			name: "Binary",
+			write: func(t *testing.T, artifactPath string) {
+				writeZip(t, artifactPath, []archiveEntry{{name: "target/release/spin", content: "binary", mode: 0644}})
+			},
+			expected: "binary",
+		},
+		{
+			name: "Release archive",
+			write: func(t *testing.T, artifactPath string) {
+				archivePath := filepath.Join(t.TempDir(), "spin-canary-linux-amd64.tar.gz")
+				writeTarGz(t, archivePath, []archiveEntry{{name: "spin", content: "archived binary", mode: 0755}})
+
+				content, err := os.ReadFile(archivePath)
+				if err != nil {
+					t.Fatalf("failed to read archive: %v", err)
+				}
+				writeZip(t, artifactPath, []archiveEntry{{name: "spin-canary-linux-amd64.tar.gz", content: string(content), mode: 0644}})
+			},
+			expected: "archived binary",
+		},
+		{
+			name: "No binary",
+			write: func(t *testing.T, artifactPath string) {
+				writeZip(t, artifactPath, []archiveEntry{{name: "results.xml", content: "<testsuites/>", mode: 0644}})
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			artifactPath := filepath.Join(dir, "artifact.zip")
+			tt.write(t, artifactPath)
+
+			destDir := filepath.Join(dir, "out")
+			if err := os.Mkdir(destDir, 0755); err != nil {
+				t.Fatalf("failed to create directory: %v", err)
+			}
+
+			err := ExtractArtifactBinary(artifactPath, destDir, "spin")
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error: %v, got: %v", tt.expectError, err)
+			}
+			if tt.expectError {
+				return
+			}
+
+			info, err := os.Stat(filepath.Join(destDir, "spin"))
+			if err != nil || info.Mode().Perm()&0111 == 0 {
+				t.Fatalf("expected an executable binary: %v", err)
+			}
+
+			if content, err := os.ReadFile(filepath.Join(destDir, "spin")); err != nil || string(content) != tt.expected {
+				t.Errorf("expected content: %q, got: %q (error: %v)", tt.expected, content, err)
+			}
+
+			// Nested archives are extracted through temporary files, which are removed
+			if entries, err := os.ReadDir(destDir); err != nil || len(entries) != 1 {
+				t.Errorf("expected only the binary to be extracted, got: %v (error: %v)", entries, err)
+			}
+		})
+	}
+}
+
+func TestArtifactSource(t *testing.T) {
+	const commit = "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/fermyon/spin/pulls/2890":
+			fmt.Fprintf(w, `{"head": {"sha": %q}}`, commit)
+		case "/repos/fermyon/spin/commits/1a2b3c4":
+			fmt.Fprintf(w, `{"sha": %q}`, commit)
+		case "/repos/fermyon/spin/actions/runs":
+			if r.URL.Query().Get("head_sha") != commit || r.URL.Query().Get("status") != "success" {
+				fmt.Fprint(w, `{"workflow_runs": []}`)
+				return
+			}
+			fmt.Fprint(w, `{"workflow_runs": [{"id": 2, "name": "Lint", "html_url": "https://github.com/fermyon/spin/actions/runs/2"}, {"id": 1, "name": "Build", "html_url": "https://github.com/fermyon/spin/actions/runs/1"}]}`)
+		case "/repos/fermyon/spin/actions/runs/2/artifacts":
+			fmt.Fprint(w, `{"artifacts": []}`)
+		case "/repos/fermyon/spin/actions/runs/1/artifacts":
+			fmt.Fprint(w, `{"artifacts": [
+				{"id": 10, "name": "spin-ubuntu-latest", "expired": true},
+				{"id": 11, "name": "spin-macos-latest", "archive_download_url": "https://api.github.com/repos/fermyon/spin/actions/artifacts/11/zip"},
+				{"id": 12, "name": "spin-windows-latest"}
+			]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	source := &ArtifactSource{Client: server.Client(), Repository: "fermyon/spin", APIURL: server.URL}
+
+	for _, ref := range []ArtifactRef{{PR: 2890}, {Commit: "1a2b3c4"}} {
+		if resolved, err := source.ResolveCommit(ref); err != nil || resolved != commit {
+			t.Errorf("expected %s to resolve to %s, got: %s (error: %v)", ref, commit, resolved, err)
+		}
+	}
+
+	if _, err := source.ResolveCommit(ArtifactRef{PR: 1}); err == nil {
+		t.Errorf("expected an unknown pull request to fail")
+	}
+
+	tests := []struct {
+		name        string
+		goos        string
+		artifact    string
+		expected    int64
+		expectError bool
+	}{
+		{name: "Matched by platform", goos: "darwin", expected: 11},
+		{name: "Named", goos: "darwin", artifact: "spin-windows-latest", expected: 12},
+		{name: "Expired", goos: "linux", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, artifact, err := source.FindArtifact(commit, tt.goos, "arm64", VariantGlibc, tt.artifact)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error: %v, got: %v", tt.expectError, err)
+			}
+			if tt.expectError {
+				return
+			}
+
+			if run.ID != 1 || artifact.ID != tt.expected {
+				t.Errorf("expected artifact %d of run 1, got: artifact %d of run %d", tt.expected, artifact.ID, run.ID)
+			}
+		})
+	}
+
+	if _, _, err := source.FindArtifact("0000000", "darwin", "arm64", "", ""); err == nil {
+		t.Errorf("expected a commit without workflow runs to fail")
+	}
+}
@@ -0,0 +1,187 @@
+package verman
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultSpinGitURL is the repository Spin is built from
+	DefaultSpinGitURL = "https://github.com/fermyon/spin.git"
+
+	// BuildVersionPrefix prefixes the names of versions built from source
+	BuildVersionPrefix = "build-"
+
+	shortCommitLength = 7
+)
+
+// unsafeRefChars match the characters of a git ref that can't be used in a version name
+var unsafeRefChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// BuildConfig configures how Spin is built from source
+type BuildConfig struct {
+	Repository string   `json:"repository,omitempty"` // Git URL of the Spin repository, defaulting to DefaultSpinGitURL
+	Features   []string `json:"features,omitempty"`   // Cargo features to enable
+}
+
+// BuildVersionName returns the name a build of ref at commit with the given cargo features is installed as, e.g.
+// "build-main-1a2b3c4", or "build-main-1a2b3c4+llm" with the "llm" feature, so that builds with different features are
+// kept apart. A ref that is itself (an abbreviation of) the commit is only included once.
+func BuildVersionName(ref, commit string, features []string) string {
+	shortCommit := commit
+	if len(shortCommit) > shortCommitLength {
+		shortCommit = shortCommit[:shortCommitLength]
+	}
+
+	for _, prefix := range []string{"refs/heads/", "refs/tags/", "origin/"} {
+		ref = strings.TrimPrefix(ref, prefix)
+	}
+
+	name := BuildVersionPrefix + shortCommit
+	if len(ref) < 4 || !strings.HasPrefix(commit, strings.ToLower(ref)) {
+		name = BuildVersionPrefix + strings.Trim(unsafeRefChars.ReplaceAllString(ref, "-"), "-.") + "-" + shortCommit
+	}
+
+	// The order features are enabled in doesn't change the build
+	enabled := enabledFeatures(features)
+	sort.Strings(enabled)
+
+	for _, feature := range enabled {
+		name += "+" + strings.Trim(unsafeRefChars.ReplaceAllString(feature, "-"), "-.")
+	}
+
+	return name
+}
+
+// CargoBuildArgs returns the arguments of the cargo command that builds Spin with the given features
+func CargoBuildArgs(features []string) []string {
+	args := []string{"build", "--release"}
+
+	if enabled := enabledFeatures(features); len(enabled) > 0 {
+		args = append(args, "--features", strings.Join(enabled, ","))
+	}
+
+	return args
+}
+
+// enabledFeatures returns the features without surrounding whitespace, dropping empty and repeated ones
+func enabledFeatures(features []string) []string {
+	var enabled []string
+	seen := map[string]bool{}
+
+	for _, feature := range features {
+		if feature = strings.TrimSpace(feature); feature != "" && !seen[feature] {
+			seen[feature] = true
+			enabled = append(enabled, feature)
+		}
+	}
+
+	return enabled
+}
+
+// BuiltBinaryPath returns the path of the Spin binary that cargo builds in a checkout for a Go OS
+func BuiltBinaryPath(checkoutDir, goos string) string {
+	return filepath.Join(checkoutDir, "target", "release", BinaryName(goos))
+}
+
+// SourceCheckout is a cached git checkout of the Spin repository that versions are built from
+type SourceCheckout struct {
+	Dir string
+	URL string
+
+	// Output receives the progress of git commands
+	Output io.Writer
+}
+
+// Checkout clones the repository if it hasn't been cloned yet, fetches ref (unless offline, in which case only what was
+// fetched before is available) and checks out its commit, which is returned. ref is a branch, tag or commit SHA.
+func (c *SourceCheckout) Checkout(ref string, offline bool) (string, error) {
+	// git would parse such a ref as an option
+	if ref == "" || strings.HasPrefix(ref, "-") {
+		return "", fmt.Errorf("%q is not a valid git ref", ref)
+	}
+
+	if _, err := os.Stat(filepath.Join(c.Dir, ".git")); err != nil {
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		if offline {
+			return "", fmt.Errorf("Spin can't be built in offline mode, as %s hasn't been cloned yet", c.URL)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(c.Dir), 0755); err != nil {
+			return "", err
+		}
+
+		if _, err := c.git("", true, "clone", "--no-checkout", "--", c.URL, c.Dir); err != nil {
+			return "", err
+		}
+	}
+
+	var commit string
+
+	if !offline {
+		// The repository may have been changed in the configuration since it was cloned
+		if _, err := c.git(c.Dir, false, "remote", "set-url", "origin", c.URL); err != nil {
+			return "", err
+		}
+
+		// Fetching the ref directly works for branches, tags and full commit SHAs. Anything else (e.g. an abbreviated
+		// SHA) has to be found among all the branches and tags.
+		if _, err := c.git(c.Dir, false, "fetch", "--force", "origin", ref); err == nil {
+			commit, _ = c.git(c.Dir, false, "rev-parse", "--verify", "FETCH_HEAD^{commit}")
+		} else if _, err := c.git(c.Dir, true, "fetch", "--force", "--tags", "origin", "+refs/heads/*:refs/remotes/origin/*"); err != nil {
+			return "", err
+		}
+	}
+
+	if commit == "" {
+		for _, candidate := range []string{"origin/" + ref, ref} {
+			if resolved, err := c.git(c.Dir, false, "rev-parse", "--verify", "--quiet", candidate+"^{commit}"); err == nil {
+				commit = resolved
+				break
+			}
+		}
+	}
+
+	if commit == "" {
+		return "", fmt.Errorf("%q is not a branch, tag or commit of %s", ref, c.URL)
+	}
+
+	if _, err := c.git(c.Dir, false, "checkout", "--force", "--detach", commit); err != nil {
+		return "", err
+	}
+
+	return commit, nil
+}
+
+// git runs a git command in dir, returning its trimmed standard output. Its standard error is included in the error if
+// the command fails, and is also streamed to the checkout's output if stream is set (for slow commands such as clone).
+func (c *SourceCheckout) git(dir string, stream bool, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if stream && c.Output != nil {
+		cmd.Stderr = io.MultiWriter(&stderr, c.Output)
+	}
+
+	// Prompting for credentials would hang a non-interactive build
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,160 @@
+package verman
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildVersionName(t *testing.T) {
+	commit := "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b"
+
+	tests := []struct {
+		ref      string
+		features []string
+		expected string
+	}{
+		{ref: "main", expected: "build-main-1a2b3c4"},
+		{ref: "v2.7.0", expected: "build-v2.7.0-1a2b3c4"},
+		{ref: "refs/heads/main", expected: "build-main-1a2b3c4"},
+		{ref: "origin/feature/wasi-p3", expected: "build-feature-wasi-p3-1a2b3c4"},
+		{ref: "1a2b3c4", expected: "build-1a2b3c4"},
+		{ref: commit, expected: "build-1a2b3c4"},
+		{ref: "1A2B3C4", expected: "build-1a2b3c4"},
+		{ref: "abc", expected: "build-abc-1a2b3c4"},
+		// Builds with different features are installed side by side
+		{ref: "main", features: []string{"llm"}, expected: "build-main-1a2b3c4+llm"},
+		{ref: "main", features: []string{" llm-metal", "llm", "llm", ""}, expected: "build-main-1a2b3c4+llm+llm-metal"},
+		{ref: commit, features: []string{"spin-cli/llm"}, expected: "build-1a2b3c4+spin-cli-llm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if name := BuildVersionName(tt.ref, commit, tt.features); name != tt.expected {
+				t.Errorf("expected: %s, got: %s", tt.expected, name)
+			}
+		})
+	}
+}
+
+func TestCargoBuildArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		features []string
+		expected []string
+	}{
+		{name: "No features", expected: []string{"build", "--release"}},
+		{name: "Features", features: []string{"llm", " llm-metal "}, expected: []string{"build", "--release", "--features", "llm,llm-metal"}},
+		{name: "Empty features", features: []string{""}, expected: []string{"build", "--release"}},
+		{name: "Repeated features", features: []string{"llm", "llm"}, expected: []string{"build", "--release", "--features", "llm"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if args := CargoBuildArgs(tt.features); !equalStringSlices(args, tt.expected) {
+				t.Errorf("expected: %v, got: %v", tt.expected, args)
+			}
+		})
+	}
+}
+
+func TestSourceCheckout(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	upstream := filepath.Join(dir, "spin")
+
+	runGit := func(args ...string) string {
+		t.Helper()
+
+		cmd := exec.Command("git", args...)
+		cmd.Dir = upstream
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+
+	commitFile := func(content string) string {
+		t.Helper()
+
+		if err := os.WriteFile(filepath.Join(upstream, "Cargo.toml"), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+		runGit("add", "Cargo.toml")
+		runGit("commit", "--quiet", "-m", content)
+		return runGit("rev-parse", "HEAD")
+	}
+
+	if err := os.Mkdir(upstream, 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	runGit("init", "--quiet", "--initial-branch=main")
+	first := commitFile("v1")
+	runGit("tag", "v1.0.0")
+	second := commitFile("v2")
+
+	checkout := &SourceCheckout{Dir: filepath.Join(dir, "cache", "source", "spin"), URL: upstream}
+
+	if _, err := checkout.Checkout("main", true); err == nil {
+		t.Errorf("expected an offline checkout to fail before the repository is cloned")
+	}
+
+	tests := []struct {
+		name     string
+		ref      string
+		offline  bool
+		expected string
+	}{
+		{name: "Branch", ref: "main", expected: second},
+		{name: "Tag", ref: "v1.0.0", expected: first},
+		{name: "Full commit", ref: first, expected: first},
+		{name: "Abbreviated commit", ref: second[:7], expected: second},
+		{name: "Offline", ref: "v1.0.0", offline: true, expected: first},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commit, err := checkout.Checkout(tt.ref, tt.offline)
+			if err != nil {
+				t.Fatalf("failed to check out %s: %v", tt.ref, err)
+			}
+
+			if commit != tt.expected {
+				t.Errorf("expected commit: %s, got: %s", tt.expected, commit)
+			}
+
+			// The working tree is at the checked out commit
+			content, err := os.ReadFile(filepath.Join(checkout.Dir, "Cargo.toml"))
+			if err != nil {
+				t.Fatalf("failed to read checkout: %v", err)
+			}
+			if expected := runGit("show", tt.expected+":Cargo.toml"); string(content) != expected {
+				t.Errorf("expected content: %q, got: %q", expected, content)
+			}
+		})
+	}
+
+	// New commits are fetched into the existing checkout
+	third := commitFile("v3")
+	if commit, err := checkout.Checkout("main", false); err != nil || commit != third {
+		t.Errorf("expected commit: %s, got: %s (error: %v)", third, commit, err)
+	}
+
+	if _, err := checkout.Checkout("no-such-branch", false); err == nil {
+		t.Errorf("expected an unknown ref to fail")
+	}
+
+	// A ref that looks like an option is never passed to git
+	for _, ref := range []string{"--upload-pack=touch pwned", "-h", ""} {
+		if _, err := checkout.Checkout(ref, false); err == nil || !strings.Contains(err.Error(), "not a valid git ref") {
+			t.Errorf("expected ref %q to be rejected, got: %v", ref, err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package verman
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// CanarySnapshotPrefix prefixes the names of previous canary builds kept by "update canary", e.g.
+	// "canary@2024-09-30-1a2b3c4"
+	CanarySnapshotPrefix = "canary@"
+
+	// CanaryPrevious is the requested version that selects the most recent previous canary build
+	CanaryPrevious = CanarySnapshotPrefix + "previous"
+
+	// DefaultCanaryKeep is how many previous canary builds are kept by default
+	DefaultCanaryKeep = 3
+)
+
+// spinVersionPattern matches the commit and build date in the output of "spin --version", e.g.
+// "spin 3.0.0-pre0 (1a2b3c4 2024-09-30)"
+var spinVersionPattern = regexp.MustCompile(`\(([0-9a-f]{7,40}) (\d{4}-\d{2}-\d{2})\)`)
+
+// CanaryConfig configures how many previous canary builds "update canary" keeps
+type CanaryConfig struct {
+	Keep *int `json:"keep,omitempty"` // Defaults to DefaultCanaryKeep; 0 keeps none
+}
+
+// KeepSnapshots returns how many previous canary builds to keep
+func (c CanaryConfig) KeepSnapshots() int {
+	if c.Keep == nil || *c.Keep < 0 {
+		return DefaultCanaryKeep
+	}
+
+	return *c.Keep
+}
+
+// ParseSpinVersion returns the commit and build date reported by "spin --version", if it reports them
+func ParseSpinVersion(output string) (commit, buildDate string, ok bool) {
+	match := spinVersionPattern.FindStringSubmatch(output)
+	if match == nil {
+		return "", "", false
+	}
+
+	return match[1], match[2], true
+}
+
+// IsCanarySnapshot indicates whether name is a previous canary build kept by "update canary"
+func IsCanarySnapshot(name string) bool {
+	return strings.HasPrefix(name, CanarySnapshotPrefix) && name != CanaryPrevious
+}
+
+// CanarySnapshotName returns the name a canary build is kept as once it has been updated: its build date (or install
+// date) followed by its short commit SHA, or the start of the binary's digest if the commit isn't known
+func CanarySnapshotName(metadata *Metadata) string {
+	id := metadata.Commit
+	if id == "" {
+		id = metadata.SHA256
+	}
+	if len(id) > shortCommitLength {
+		id = id[:shortCommitLength]
+	}
+
+	return CanarySnapshotPrefix + canaryDate(metadata) + "-" + id
+}
+
+// canaryDate returns the date a canary build is named and ordered by: its build date, or its install date if the
+// build date isn't known
+func canaryDate(metadata *Metadata) string {
+	if metadata.BuildDate != "" {
+		return metadata.BuildDate
+	}
+
+	return metadata.InstalledAt.UTC().Format(time.DateOnly)
+}
+
+// SameCanaryBuild indicates whether two canary builds are the same, comparing their commits or, if either isn't
+// known, the digests of their binaries
+func SameCanaryBuild(a, b *Metadata) bool {
+	if a.Commit != "" && b.Commit != "" {
+		return a.Commit == b.Commit
+	}
+
+	return a.SHA256 != "" && a.SHA256 == b.SHA256
+}
+
+// CanarySnapshots returns the previous canary builds in the versions directory, most recent first
+func (r *Repository) CanarySnapshots() ([]*InstalledVersion, error) {
+	versions, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []*InstalledVersion
+	for _, version := range versions {
+		if IsCanarySnapshot(version.Name) {
+			snapshots = append(snapshots, version)
+		}
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		a, b := snapshots[i].Metadata, snapshots[j].Metadata
+		if dateA, dateB := canaryDate(a), canaryDate(b); dateA != dateB {
+			return dateA > dateB
+		}
+		return a.InstalledAt.After(b.InstalledAt)
+	})
+
+	return snapshots, nil
+}
+
+// PreviousCanary returns the name of the most recent previous canary build
+func (r *Repository) PreviousCanary() (string, error) {
+	snapshots, err := r.CanarySnapshots()
+	if err != nil {
+		return "", err
+	}
+
+	if len(snapshots) == 0 {
+		return "", fmt.Errorf("no previous canary builds are installed; they are kept when running \"spin verman update canary\"")
+	}
+
+	return snapshots[0].Name, nil
+}
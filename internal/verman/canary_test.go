@@ -0,0 +1,156 @@
+package verman
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseSpinVersion(t *testing.T) {
+	tests := []struct {
+		output            string
+		expectedCommit    string
+		expectedBuildDate string
+		expectedOK        bool
+	}{
+		{output: "spin 3.0.0-pre0 (1a2b3c4 2024-09-30)\n", expectedCommit: "1a2b3c4", expectedBuildDate: "2024-09-30", expectedOK: true},
+		{output: "spin 2.7.0 (202f5b6 2024-07-23)", expectedCommit: "202f5b6", expectedBuildDate: "2024-07-23", expectedOK: true},
+		{output: "spin 2.7.0"},
+		{output: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			commit, buildDate, ok := ParseSpinVersion(tt.output)
+			if commit != tt.expectedCommit || buildDate != tt.expectedBuildDate || ok != tt.expectedOK {
+				t.Errorf("expected %q, %q, %v, got: %q, %q, %v", tt.expectedCommit, tt.expectedBuildDate, tt.expectedOK, commit, buildDate, ok)
+			}
+		})
+	}
+}
+
+func TestCanarySnapshotName(t *testing.T) {
+	installedAt := time.Date(2024, 10, 2, 23, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		metadata Metadata
+		expected string
+	}{
+		{name: "Commit and build date", metadata: Metadata{Commit: "1a2b3c4d5e6f", BuildDate: "2024-09-30", SHA256: "ffff0000ffff", InstalledAt: installedAt}, expected: "canary@2024-09-30-1a2b3c4"},
+		{name: "Install date", metadata: Metadata{Commit: "1a2b3c4", InstalledAt: installedAt}, expected: "canary@2024-10-02-1a2b3c4"},
+		{name: "Digest", metadata: Metadata{SHA256: "ffff0000ffff", InstalledAt: installedAt}, expected: "canary@2024-10-02-ffff000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if name := CanarySnapshotName(&tt.metadata); name != tt.expected {
+				t.Errorf("expected: %s, got: %s", tt.expected, name)
+			}
+
+			if !IsCanarySnapshot(tt.expected) {
+				t.Errorf("expected %s to be a canary snapshot", tt.expected)
+			}
+		})
+	}
+
+	if IsCanarySnapshot("canary") || IsCanarySnapshot(CanaryPrevious) {
+		t.Errorf("expected canary and %s not to be snapshots", CanaryPrevious)
+	}
+}
+
+func TestCanarySnapshots(t *testing.T) {
+	dir := t.TempDir()
+	repository := NewRepository(dir)
+
+	if _, err := repository.PreviousCanary(); err == nil {
+		t.Errorf("expected an error without previous canary builds")
+	}
+
+	installedAt := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	for i, metadata := range []*Metadata{
+		{Version: "canary@2024-09-28-aaaaaaa", Kind: KindCanary, BuildDate: "2024-09-28", InstalledAt: installedAt},
+		// Named after its install date, as its build date isn't known
+		{Version: "canary@2024-09-29-ddddddd", Kind: KindCanary, InstalledAt: time.Date(2024, 9, 29, 12, 0, 0, 0, time.UTC)},
+		{Version: "canary@2024-09-30-bbbbbbb", Kind: KindCanary, BuildDate: "2024-09-30", InstalledAt: installedAt},
+		{Version: "canary@2024-09-30-ccccccc", Kind: KindCanary, BuildDate: "2024-09-30", InstalledAt: installedAt.Add(time.Hour)},
+		{Version: "canary", Kind: KindCanary, BuildDate: "2024-10-01", InstalledAt: installedAt},
+		{Version: "v2.7.0", Kind: KindRelease, InstalledAt: installedAt},
+	} {
+		versionDir := filepath.Join(dir, metadata.Version)
+		if err := os.MkdirAll(versionDir, 0755); err != nil {
+			t.Fatalf("failed to create version directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(versionDir, "spin"), []byte{byte(i)}, 0755); err != nil {
+			t.Fatalf("failed to write binary: %v", err)
+		}
+		if err := WriteMetadata(versionDir, metadata); err != nil {
+			t.Fatalf("failed to write metadata: %v", err)
+		}
+	}
+
+	snapshots, err := repository.CanarySnapshots()
+	if err != nil {
+		t.Fatalf("failed to list canary snapshots: %v", err)
+	}
+
+	var names []string
+	for _, snapshot := range snapshots {
+		names = append(names, snapshot.Name)
+	}
+
+	// Builds of the same day are ordered by when they were installed
+	expected := []string{"canary@2024-09-30-ccccccc", "canary@2024-09-30-bbbbbbb", "canary@2024-09-29-ddddddd", "canary@2024-09-28-aaaaaaa"}
+	if !equalStringSlices(names, expected) {
+		t.Errorf("expected snapshots: %v, got: %v", expected, names)
+	}
+
+	if previous, err := repository.PreviousCanary(); err != nil || previous != expected[0] {
+		t.Errorf("expected previous canary: %s, got: %s (error: %v)", expected[0], previous, err)
+	}
+}
+
+func TestSameCanaryBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     Metadata
+		expected bool
+	}{
+		{name: "Same commit", a: Metadata{Commit: "1a2b3c4", SHA256: "aaaa"}, b: Metadata{Commit: "1a2b3c4", SHA256: "bbbb"}, expected: true},
+		{name: "Different commit", a: Metadata{Commit: "1a2b3c4", SHA256: "aaaa"}, b: Metadata{Commit: "5d6e7f8", SHA256: "aaaa"}},
+		{name: "Same digest", a: Metadata{SHA256: "aaaa"}, b: Metadata{Commit: "1a2b3c4", SHA256: "aaaa"}, expected: true},
+		{name: "Different digest", a: Metadata{SHA256: "aaaa"}, b: Metadata{SHA256: "bbbb"}},
+		{name: "Unknown", a: Metadata{}, b: Metadata{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if same := SameCanaryBuild(&tt.a, &tt.b); same != tt.expected {
+				t.Errorf("expected: %v, got: %v", tt.expected, same)
+			}
+		})
+	}
+}
+
+func TestCanaryConfigKeepSnapshots(t *testing.T) {
+	none, negative := 0, -1
+
+	tests := []struct {
+		name     string
+		config   CanaryConfig
+		expected int
+	}{
+		{name: "Default", expected: DefaultCanaryKeep},
+		{name: "None", config: CanaryConfig{Keep: &none}, expected: 0},
+		{name: "Negative", config: CanaryConfig{Keep: &negative}, expected: DefaultCanaryKeep},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if keep := tt.config.KeepSnapshots(); keep != tt.expected {
+				t.Errorf("expected: %d, got: %d", tt.expected, keep)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package verman
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DigestFileName is the file, stored next to an installed Spin binary, that records the binary's SHA-256 digest
+	DigestFileName = "spin.sha256"
+)
+
+// ChecksumsFileName returns the name of the checksums asset Spin publishes alongside the given release
+func ChecksumsFileName(version string) string {
+	return fmt.Sprintf("checksums-%s.txt", version)
+}
+
+// ParseChecksums parses "sha256sum"-formatted content, returning a map of file names to hex-encoded SHA-256 digests
+func ParseChecksums(r io.Reader) (map[string]string, error) {
+	checksums := map[string]string{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("malformed checksum line %q", line)
+		}
+
+		digest := strings.ToLower(fields[0])
+		if _, err := hex.DecodeString(digest); err != nil || len(digest) != sha256.Size*2 {
+			return nil, fmt.Errorf("malformed SHA-256 digest %q", fields[0])
+		}
+
+		// sha256sum prefixes file names with "*" when run in binary mode
+		checksums[strings.TrimPrefix(fields[1], "*")] = digest
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return checksums, nil
+}
+
+// FileSHA256 returns the hex-encoded SHA-256 digest of the file at the given path
+func FileSHA256(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// WriteDigestFile records the digest of the named file in "sha256sum" format at digestPath
+func WriteDigestFile(digestPath, fileName, digest string) error {
+	return os.WriteFile(digestPath, []byte(fmt.Sprintf("%s  %s\n", digest, fileName)), 0644)
+}
+
+// ReadDigestFile returns the digest recorded for the named file in the digest file at digestPath,
+// or an empty string if the digest file doesn't exist or doesn't list the file
+func ReadDigestFile(digestPath, fileName string) (string, error) {
+	f, err := os.Open(digestPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+
+		return "", err
+	}
+	defer f.Close()
+
+	checksums, err := ParseChecksums(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %v", digestPath, err)
+	}
+
+	return checksums[fileName], nil
+}
+
+// VerifyDigestFile re-computes the digest of every file listed in the digest file at digestPath (relative to dir)
+// and returns an error if any of them do not match
+func VerifyDigestFile(dir, digestPath string) error {
+	f, err := os.Open(digestPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	checksums, err := ParseChecksums(f)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", digestPath, err)
+	}
+
+	for fileName, expected := range checksums {
+		actual, err := FileSHA256(filepath.Join(dir, fileName))
+		if err != nil {
+			return err
+		}
+
+		if actual != expected {
+			return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", fileName, expected, actual)
+		}
+	}
+
+	return nil
+}
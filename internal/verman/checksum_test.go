@@ -0,0 +1,91 @@
+package verman
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseChecksums(t *testing.T) {
+	digest := strings.Repeat("ab", 32)
+
+	tests := []struct {
+		name        string
+		content     string
+		expected    map[string]string
+		expectError bool
+	}{
+		{
+			name:     "Text and binary mode entries",
+			content:  digest + "  spin-v2.7.0-linux-amd64.tar.gz\n" + strings.ToUpper(digest) + " *spin-v2.7.0-macos-aarch64.tar.gz\n\n",
+			expected: map[string]string{"spin-v2.7.0-linux-amd64.tar.gz": digest, "spin-v2.7.0-macos-aarch64.tar.gz": digest},
+		},
+		{
+			name:        "Missing file name",
+			content:     digest + "\n",
+			expectError: true,
+		},
+		{
+			name:        "Digest is not SHA-256",
+			content:     "abcd  spin-v2.7.0-linux-amd64.tar.gz\n",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checksums, err := ParseChecksums(strings.NewReader(tt.content))
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error: %v, got: %v", tt.expectError, err)
+			}
+			if len(checksums) != len(tt.expected) {
+				t.Fatalf("expected checksums: %v, got: %v", tt.expected, checksums)
+			}
+			for fileName, expected := range tt.expected {
+				if checksums[fileName] != expected {
+					t.Errorf("expected digest for %s: %v, got: %v", fileName, expected, checksums[fileName])
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyDigestFile(t *testing.T) {
+	dir := t.TempDir()
+	binaryPath := filepath.Join(dir, "spin")
+	digestPath := filepath.Join(dir, DigestFileName)
+
+	if err := os.WriteFile(binaryPath, []byte("spin binary"), 0755); err != nil {
+		t.Fatalf("failed to write binary: %v", err)
+	}
+
+	digest, err := FileSHA256(binaryPath)
+	if err != nil {
+		t.Fatalf("failed to hash binary: %v", err)
+	}
+
+	if err := WriteDigestFile(digestPath, "spin", digest); err != nil {
+		t.Fatalf("failed to write digest file: %v", err)
+	}
+
+	if err := VerifyDigestFile(dir, digestPath); err != nil {
+		t.Errorf("expected unmodified binary to verify, got: %v", err)
+	}
+
+	if recorded, err := ReadDigestFile(digestPath, "spin"); err != nil || recorded != digest {
+		t.Errorf("expected recorded digest: %v, got: %v (error: %v)", digest, recorded, err)
+	}
+
+	if recorded, err := ReadDigestFile(filepath.Join(dir, "missing.sha256"), "spin"); err != nil || recorded != "" {
+		t.Errorf("expected no recorded digest for a missing digest file, got: %v (error: %v)", recorded, err)
+	}
+
+	if err := os.WriteFile(binaryPath, []byte("tampered spin binary"), 0755); err != nil {
+		t.Fatalf("failed to modify binary: %v", err)
+	}
+
+	if err := VerifyDigestFile(dir, digestPath); err == nil {
+		t.Errorf("expected modified binary to fail verification")
+	}
+}
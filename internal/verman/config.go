@@ -0,0 +1,36 @@
+package verman
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ConfigFileName is the name of verman's configuration file in the config directory
+const ConfigFileName = "config.json"
+
+// Config is verman's user configuration
+type Config struct {
+	ReleaseSource ReleaseSourceConfig `json:"release_source"`
+	HTTP          HTTPConfig          `json:"http"`
+	Build         BuildConfig         `json:"build"`
+	Canary        CanaryConfig        `json:"canary"`
+}
+
+// LoadConfig reads the configuration file at configPath. A missing file is an empty configuration.
+func LoadConfig(configPath string) (*Config, error) {
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Config{}, nil
+		}
+		return nil, err
+	}
+
+	var config Config
+	if err := json.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %v", configPath, err)
+	}
+
+	return &config, nil
+}
@@ -0,0 +1,288 @@
+package verman
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// comparator is a single "<op> <version>" term of a version constraint, where version is canonical ("vX.Y.Z")
+type comparator struct {
+	op      string
+	version string
+}
+
+// constraint is a set of comparator groups: a version satisfies the constraint if it satisfies every
+// comparator in at least one group (i.e. groups are joined with "||" and comparators within a group with spaces)
+type constraint struct {
+	groups     [][]comparator
+	prerelease bool
+}
+
+// IsVersionConstraint reports whether spec is a version constraint (such as "2.7", "~2.6", "^2.5", ">=2.5 <3", "2.x" or "lts")
+// rather than an exact version, the canary version or an alias. Spin has no long-term support line, so "lts", like
+// "stable", "*" and "x", matches any stable release and resolves to the latest one.
+func IsVersionConstraint(spec string) bool {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "canary" {
+		return false
+	}
+
+	// Exact versions are not constraints, with or without the "v" prefix
+	if parts, _, err := parsePartialVersion(spec); err == nil && len(parts) == 3 {
+		return false
+	}
+
+	_, err := parseConstraint(spec)
+	return err == nil
+}
+
+// ResolveVersion returns the highest of the candidate versions that satisfies the version constraint spec.
+// Candidates that are not valid semantic versions (e.g. "canary" or aliases) are ignored.
+func ResolveVersion(spec string, candidates []string) (string, error) {
+	c, err := parseConstraint(spec)
+	if err != nil {
+		return "", err
+	}
+
+	var best string
+	for _, candidate := range candidates {
+		v := canonicalVersion(candidate)
+		if !semver.IsValid(v) || !c.matches(v) {
+			continue
+		}
+
+		if best == "" || semver.Compare(v, canonicalVersion(best)) > 0 {
+			best = candidate
+		}
+	}
+
+	if best == "" {
+		return "", fmt.Errorf("no version of Spin matches %q", spec)
+	}
+
+	return best, nil
+}
+
+func canonicalVersion(version string) string {
+	if strings.HasPrefix(version, "v") {
+		return version
+	}
+
+	return "v" + version
+}
+
+func (c *constraint) matches(version string) bool {
+	// Pre-releases are only considered when the constraint itself mentions one
+	if semver.Prerelease(version) != "" && !c.prerelease {
+		return false
+	}
+
+	for _, group := range c.groups {
+		matched := true
+		for _, cmp := range group {
+			if !cmp.matches(version) {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (cmp comparator) matches(version string) bool {
+	result := semver.Compare(version, cmp.version)
+
+	switch cmp.op {
+	case "=":
+		return result == 0
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	}
+
+	return false
+}
+
+func parseConstraint(spec string) (*constraint, error) {
+	c := &constraint{}
+
+	for _, groupSpec := range strings.Split(spec, "||") {
+		var group []comparator
+
+		terms := strings.Fields(groupSpec)
+		if len(terms) == 0 {
+			return nil, fmt.Errorf("invalid version constraint %q", spec)
+		}
+
+		for i := 0; i < len(terms); i++ {
+			term := terms[i]
+
+			// Allow a space between an operator and its version (e.g. ">= 2.5")
+			if strings.Trim(term, "<>=~^") == "" && i+1 < len(terms) {
+				term += terms[i+1]
+				i++
+			}
+
+			comparators, err := parseTerm(term)
+			if err != nil {
+				return nil, fmt.Errorf("invalid version constraint %q: %v", spec, err)
+			}
+
+			for _, cmp := range comparators {
+				if semver.Prerelease(cmp.version) != "" {
+					c.prerelease = true
+				}
+			}
+
+			group = append(group, comparators...)
+		}
+
+		c.groups = append(c.groups, group)
+	}
+
+	return c, nil
+}
+
+// parseTerm expands a single constraint term into the comparators it is equivalent to
+func parseTerm(term string) ([]comparator, error) {
+	switch strings.ToLower(term) {
+	case "lts", "stable", "*", "x":
+		// Any stable release; pre-releases are excluded by constraint.matches. Spin has no LTS releases, so "lts" is an
+		// alias for the latest stable release rather than a release line of its own.
+		return []comparator{{">=", "v0.0.0"}}, nil
+	}
+
+	var op string
+	for _, candidate := range []string{">=", "<=", ">", "<", "=", "~", "^"} {
+		if strings.HasPrefix(term, candidate) {
+			op = candidate
+			break
+		}
+	}
+
+	parts, prerelease, err := parsePartialVersion(strings.TrimPrefix(term, op))
+	if err != nil {
+		return nil, err
+	}
+
+	lower := versionFromParts(parts, prerelease)
+
+	// A partial version without an operator, or with "=", covers every version with that prefix
+	if op == "" || op == "=" {
+		if len(parts) == 3 {
+			return []comparator{{"=", lower}}, nil
+		}
+
+		op = "~"
+	}
+
+	switch op {
+	case "~":
+		if len(parts) == 0 {
+			return []comparator{{">=", "v0.0.0"}}, nil
+		}
+
+		if len(parts) == 1 {
+			return []comparator{{">=", lower}, {"<", versionFromParts([]int{parts[0] + 1}, "")}}, nil
+		}
+
+		return []comparator{{">=", lower}, {"<", versionFromParts([]int{parts[0], parts[1] + 1}, "")}}, nil
+	case "^":
+		// The upper bound is the next increment of the left-most non-zero component
+		for i, part := range parts {
+			if part != 0 || i == len(parts)-1 {
+				upper := append([]int{}, parts[:i]...)
+				return []comparator{{">=", lower}, {"<", versionFromParts(append(upper, part+1), "")}}, nil
+			}
+		}
+
+		return []comparator{{">=", "v0.0.0"}}, nil
+	case ">=", "<":
+		return []comparator{{op, lower}}, nil
+	case ">", "<=":
+		// A partial version includes every version with that prefix, so e.g. ">2.5" means ">=2.6.0"
+		if len(parts) < 3 {
+			upper := versionFromParts(incrementLast(parts), "")
+			if op == ">" {
+				return []comparator{{">=", upper}}, nil
+			}
+
+			return []comparator{{"<", upper}}, nil
+		}
+
+		return []comparator{{op, lower}}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported operator %q", op)
+}
+
+// parsePartialVersion parses versions such as "2", "2.7", "2.x", "v2.7.0" and "2.7.0-rc.1", stopping at the first wildcard
+func parsePartialVersion(version string) ([]int, string, error) {
+	version = strings.TrimPrefix(version, "v")
+	if version == "" {
+		return nil, "", fmt.Errorf("missing version")
+	}
+
+	version, prerelease, _ := strings.Cut(version, "-")
+
+	components := strings.Split(version, ".")
+	if len(components) > 3 {
+		return nil, "", fmt.Errorf("%q has too many components", version)
+	}
+
+	var parts []int
+	for _, component := range components {
+		if component == "x" || component == "X" || component == "*" {
+			break
+		}
+
+		part, err := strconv.Atoi(component)
+		if err != nil || part < 0 {
+			return nil, "", fmt.Errorf("%q is not a valid version", version)
+		}
+
+		parts = append(parts, part)
+	}
+
+	if prerelease != "" && len(parts) != 3 {
+		return nil, "", fmt.Errorf("pre-release %q requires a full version", prerelease)
+	}
+
+	return parts, prerelease, nil
+}
+
+func versionFromParts(parts []int, prerelease string) string {
+	full := []int{0, 0, 0}
+	copy(full, parts)
+
+	version := fmt.Sprintf("v%d.%d.%d", full[0], full[1], full[2])
+	if prerelease != "" {
+		version += "-" + prerelease
+	}
+
+	return version
+}
+
+func incrementLast(parts []int) []int {
+	if len(parts) == 0 {
+		return []int{0}
+	}
+
+	incremented := append([]int{}, parts...)
+	incremented[len(incremented)-1]++
+
+	return incremented
+}
@@ -0,0 +1,77 @@
+package verman
+
+import "testing"
+
+func TestResolveVersion(t *testing.T) {
+	candidates := []string{"v1.5.1", "v2.4.3", "v2.5.0", "v2.5.1", "v2.6.0", "v2.7.0", "v3.0.0-rc.1", "canary", "myalias"}
+
+	tests := []struct {
+		spec        string
+		expected    string
+		expectError bool
+	}{
+		{spec: "2.5", expected: "v2.5.1"},
+		{spec: "v2.5", expected: "v2.5.1"},
+		{spec: "2.x", expected: "v2.7.0"},
+		{spec: "2.5.x", expected: "v2.5.1"},
+		{spec: "~2.6", expected: "v2.6.0"},
+		{spec: "~2.5.0", expected: "v2.5.1"},
+		{spec: "^2.5", expected: "v2.7.0"},
+		{spec: "^1.0.0", expected: "v1.5.1"},
+		{spec: ">=2.5 <2.7", expected: "v2.6.0"},
+		{spec: ">= 2.5 < 3", expected: "v2.7.0"},
+		{spec: ">2.5", expected: "v2.7.0"},
+		{spec: "<=2.5", expected: "v2.5.1"},
+		{spec: "<2.5", expected: "v2.4.3"},
+		{spec: "1.x || 2.4", expected: "v2.4.3"},
+		// Spin has no LTS releases, so these all select the latest stable release, skipping v3.0.0-rc.1
+		{spec: "lts", expected: "v2.7.0"},
+		{spec: "LTS", expected: "v2.7.0"},
+		{spec: "stable", expected: "v2.7.0"},
+		{spec: "*", expected: "v2.7.0"},
+		{spec: "x", expected: "v2.7.0"},
+		{spec: ">=3.0.0-rc.1", expected: "v3.0.0-rc.1"},
+		{spec: "4", expectError: true},
+		{spec: "~", expectError: true},
+		{spec: "not-a-version", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			version, err := ResolveVersion(tt.spec, candidates)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error: %v, got: %v", tt.expectError, err)
+			}
+			if version != tt.expected {
+				t.Errorf("expected version: %v, got: %v", tt.expected, version)
+			}
+		})
+	}
+}
+
+func TestIsVersionConstraint(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected bool
+	}{
+		{spec: "2.7.0", expected: false},
+		{spec: "v2.7.0", expected: false},
+		{spec: "v3.0.0-rc.1", expected: false},
+		{spec: "canary", expected: false},
+		{spec: "myalias", expected: false},
+		{spec: "2.7", expected: true},
+		{spec: "2.x", expected: true},
+		{spec: "^2.5", expected: true},
+		{spec: ">=2.5 <3", expected: true},
+		{spec: "lts", expected: true},
+		{spec: "stable", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			if result := IsVersionConstraint(tt.spec); result != tt.expected {
+				t.Errorf("expected: %v, got: %v", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package verman
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// HomeEnvVar overrides the directory verman keeps all of its files in
+	HomeEnvVar = "SPIN_VERMAN_HOME"
+
+	// LegacyHomeDirName is the directory in the user's home directory that verman used before it followed the XDG Base
+	// Directory specification
+	LegacyHomeDirName = ".spin_verman"
+
+	// PlatformsDirName is the directory in the versions directory containing the versions downloaded for other platforms
+	PlatformsDirName = "platforms"
+
+	xdgAppName = "spin-verman"
+)
+
+// Dirs are the directories verman stores its files in
+type Dirs struct {
+	Data   string // Installed Spin versions
+	Cache  string // Downloaded archives and other files that can be fetched again
+	Config string // User configuration
+	State  string // Locks and other state that doesn't need to be backed up
+}
+
+// VersionsDir is the directory containing the installed Spin versions
+func (d Dirs) VersionsDir() string {
+	return filepath.Join(d.Data, "versions")
+}
+
+// PlatformVersionsDir is the directory containing the Spin versions downloaded for another OS and architecture, which
+// is kept apart from the versions that can be run on this machine
+func (d Dirs) PlatformVersionsDir(goos, goarch string) string {
+	return filepath.Join(d.VersionsDir(), PlatformsDirName, goos+"-"+goarch)
+}
+
+// HomeDirs returns the directories verman uses when everything is kept under a single home directory, which matches the
+// layout of the legacy ~/.spin_verman directory
+func HomeDirs(home string) Dirs {
+	return Dirs{
+		Data:   home,
+		Cache:  filepath.Join(home, "cache"),
+		Config: home,
+		State:  home,
+	}
+}
+
+// XDGDirs returns the directories verman uses under the XDG Base Directory specification, honouring the
+// $XDG_DATA_HOME, $XDG_CACHE_HOME, $XDG_CONFIG_HOME and $XDG_STATE_HOME overrides
+func XDGDirs(userHome string) Dirs {
+	return Dirs{
+		Data:   xdgDir("XDG_DATA_HOME", userHome, ".local", "share"),
+		Cache:  xdgDir("XDG_CACHE_HOME", userHome, ".cache"),
+		Config: xdgDir("XDG_CONFIG_HOME", userHome, ".config"),
+		State:  xdgDir("XDG_STATE_HOME", userHome, ".local", "state"),
+	}
+}
+
+// xdgDir returns the verman directory under $envVar, or under the default location if $envVar isn't an absolute path
+// (relative paths are invalid according to the specification and must be ignored)
+func xdgDir(envVar, userHome string, defaultDir ...string) string {
+	base := os.Getenv(envVar)
+	if !filepath.IsAbs(base) {
+		base = filepath.Join(append([]string{userHome}, defaultDir...)...)
+	}
+
+	return filepath.Join(base, xdgAppName)
+}
+
+// ResolveDirs returns the directories verman should use. The home argument (e.g. from a command line flag) takes
+// precedence over $SPIN_VERMAN_HOME, and both keep everything in a single directory. Otherwise the XDG directories are
+// used.
+func ResolveDirs(home string) (Dirs, error) {
+	if home == "" {
+		home = os.Getenv(HomeEnvVar)
+	}
+
+	if home != "" {
+		absHome, err := filepath.Abs(home)
+		if err != nil {
+			return Dirs{}, fmt.Errorf("invalid verman home %q: %v", home, err)
+		}
+
+		return HomeDirs(absHome), nil
+	}
+
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return Dirs{}, err
+	}
+
+	return XDGDirs(userHome), nil
+}
+
+// LegacyHomePending indicates whether a legacy ~/.spin_verman directory still has to be migrated into dirs: it is a real
+// directory (rather than the symlink left by a migration) and the data directory isn't in use yet
+func LegacyHomePending(legacyHome string, dirs Dirs) (bool, error) {
+	info, err := os.Lstat(legacyHome)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	// A symlink means the migration has already happened
+	if !info.IsDir() {
+		return false, nil
+	}
+
+	if _, err := os.Lstat(dirs.VersionsDir()); err == nil {
+		return false, nil
+	} else if !os.IsNotExist(err) {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// MigrateLegacyHome moves the versions installed in a legacy ~/.spin_verman directory into dirs, then replaces the legacy
+// directory with a symlink to the new data directory so that $PATH entries and symlinks pointing into it keep working.
+// Only the files verman created there are removed: if anything else is left, the legacy directory is kept and just its
+// versions directory links to the new location. It returns false without doing anything if there is nothing to migrate
+// or the data directory is already in use. The caller must hold the lock on the versions directory.
+func MigrateLegacyHome(legacyHome string, dirs Dirs) (bool, error) {
+	pending, err := LegacyHomePending(legacyHome, dirs)
+	if err != nil || !pending {
+		return false, err
+	}
+
+	if err := os.MkdirAll(dirs.Data, 0755); err != nil {
+		return false, err
+	}
+
+	if err := os.Rename(filepath.Join(legacyHome, "versions"), dirs.VersionsDir()); err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to move %s to %s: %v", legacyHome, dirs.Data, err)
+	}
+
+	// The download cache and lock files can be recreated, so they are removed rather than moved
+	if err := removeLegacyFiles(legacyHome); err != nil {
+		return true, fmt.Errorf("moved installed versions to %s but failed to clean up %s: %v", dirs.Data, legacyHome, err)
+	}
+
+	if err := os.Remove(legacyHome); err != nil {
+		if err := os.Symlink(dirs.VersionsDir(), filepath.Join(legacyHome, "versions")); err != nil {
+			return true, fmt.Errorf("moved installed versions to %s but failed to link %s to it: %v", dirs.Data, legacyHome, err)
+		}
+
+		return true, fmt.Errorf("moved installed versions to %s; %s was kept as it contains other files, and its versions directory links to the new location", dirs.Data, legacyHome)
+	}
+
+	if err := os.Symlink(dirs.Data, legacyHome); err != nil {
+		return true, fmt.Errorf("moved installed versions to %s but failed to link %s to it: %v", dirs.Data, legacyHome, err)
+	}
+
+	return true, nil
+}
+
+// removeLegacyFiles removes the download cache and lock files verman kept in a legacy home directory. Directories are
+// only removed once they are empty, so files verman doesn't know about are never deleted.
+func removeLegacyFiles(legacyHome string) error {
+	downloadsDir := filepath.Join(legacyHome, "cache", "downloads")
+	if err := os.RemoveAll(downloadsDir); err != nil {
+		return err
+	}
+
+	lockFiles, err := filepath.Glob(filepath.Join(legacyHome, "*.lock"))
+	if err != nil {
+		return err
+	}
+
+	pidFiles, err := filepath.Glob(filepath.Join(legacyHome, "*.lock"+fallbackLockSuffix))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range append(lockFiles, pidFiles...) {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	// The cache directory may contain files verman doesn't know about
+	os.Remove(filepath.Join(legacyHome, "cache"))
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package verman
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveDirs(t *testing.T) {
+	userHome := t.TempDir()
+	t.Setenv("HOME", userHome)
+	for _, envVar := range []string{HomeEnvVar, "XDG_DATA_HOME", "XDG_CACHE_HOME", "XDG_CONFIG_HOME", "XDG_STATE_HOME"} {
+		t.Setenv(envVar, "")
+	}
+
+	tests := []struct {
+		name     string
+		home     string
+		env      map[string]string
+		expected Dirs
+	}{
+		{
+			name: "XDG defaults",
+			expected: Dirs{
+				Data:   filepath.Join(userHome, ".local", "share", "spin-verman"),
+				Cache:  filepath.Join(userHome, ".cache", "spin-verman"),
+				Config: filepath.Join(userHome, ".config", "spin-verman"),
+				State:  filepath.Join(userHome, ".local", "state", "spin-verman"),
+			},
+		},
+		{
+			name: "XDG overrides",
+			env:  map[string]string{"XDG_DATA_HOME": "/data", "XDG_CACHE_HOME": "relative/cache"},
+			expected: Dirs{
+				Data:   filepath.Join("/data", "spin-verman"),
+				Cache:  filepath.Join(userHome, ".cache", "spin-verman"),
+				Config: filepath.Join(userHome, ".config", "spin-verman"),
+				State:  filepath.Join(userHome, ".local", "state", "spin-verman"),
+			},
+		},
+		{
+			name:     "Home from the environment",
+			env:      map[string]string{HomeEnvVar: "/opt/verman", "XDG_DATA_HOME": "/data"},
+			expected: Dirs{Data: "/opt/verman", Cache: filepath.Join("/opt/verman", "cache"), Config: "/opt/verman", State: "/opt/verman"},
+		},
+		{
+			name:     "Home argument takes precedence",
+			home:     "/srv/verman",
+			env:      map[string]string{HomeEnvVar: "/opt/verman"},
+			expected: Dirs{Data: "/srv/verman", Cache: filepath.Join("/srv/verman", "cache"), Config: "/srv/verman", State: "/srv/verman"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for envVar, value := range tt.env {
+				t.Setenv(envVar, value)
+			}
+
+			dirs, err := ResolveDirs(tt.home)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if dirs != tt.expected {
+				t.Errorf("expected dirs: %+v, got: %+v", tt.expected, dirs)
+			}
+		})
+	}
+
+	// Versions for other platforms are kept apart from the ones that can be run
+	if dir := HomeDirs("/opt/verman").PlatformVersionsDir("linux", "arm64"); dir != filepath.Join("/opt/verman", "versions", "platforms", "linux-arm64") {
+		t.Errorf("unexpected platform versions directory: %s", dir)
+	}
+}
+
+func TestMigrateLegacyHome(t *testing.T) {
+	root := t.TempDir()
+	legacyHome := filepath.Join(root, LegacyHomeDirName)
+	dirs := XDGDirs(root)
+
+	if migrated, err := MigrateLegacyHome(legacyHome, dirs); err != nil || migrated {
+		t.Fatalf("expected nothing to migrate, got: %v (error: %v)", migrated, err)
+	}
+
+	binaryPath := filepath.Join(legacyHome, "versions", "v2.7.0", "spin")
+	if err := os.MkdirAll(filepath.Dir(binaryPath), 0755); err != nil {
+		t.Fatalf("failed to create legacy version: %v", err)
+	}
+	if err := os.WriteFile(binaryPath, []byte("spin binary"), 0755); err != nil {
+		t.Fatalf("failed to write legacy binary: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(legacyHome, "cache", "downloads"), 0755); err != nil {
+		t.Fatalf("failed to create legacy cache: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(legacyHome, "verman.lock"), nil, 0644); err != nil {
+		t.Fatalf("failed to write legacy lock file: %v", err)
+	}
+
+	if pending, err := LegacyHomePending(legacyHome, dirs); err != nil || !pending {
+		t.Fatalf("expected legacy home to be pending migration, got: %v (error: %v)", pending, err)
+	}
+
+	migrated, err := MigrateLegacyHome(legacyHome, dirs)
+	if err != nil || !migrated {
+		t.Fatalf("expected legacy home to be migrated, got: %v (error: %v)", migrated, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dirs.VersionsDir(), "v2.7.0", "spin")); err != nil {
+		t.Errorf("expected binary to be moved to the data directory: %v", err)
+	}
+
+	// The old location must keep resolving to the installed binaries
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Errorf("expected legacy path to resolve through the symlink: %v", err)
+	}
+
+	if migrated, err := MigrateLegacyHome(legacyHome, dirs); err != nil || migrated {
+		t.Errorf("expected the migration to only happen once, got: %v (error: %v)", migrated, err)
+	}
+}
+
+func TestMigrateLegacyHomeKeepsUnknownFiles(t *testing.T) {
+	root := t.TempDir()
+	legacyHome := filepath.Join(root, LegacyHomeDirName)
+	dirs := XDGDirs(root)
+
+	for _, path := range []string{
+		filepath.Join(legacyHome, "versions", "v2.7.0", "spin"),
+		filepath.Join(legacyHome, "cache", "downloads", "spin-v2.7.0-linux-amd64.tar.gz"),
+		filepath.Join(legacyHome, "verman.lock"),
+		filepath.Join(legacyHome, "notes.txt"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+
+	// The legacy directory is kept, so the migration reports that it wasn't fully replaced
+	if migrated, err := MigrateLegacyHome(legacyHome, dirs); err == nil || !migrated {
+		t.Fatalf("expected a partial migration, got: %v (error: %v)", migrated, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(legacyHome, "notes.txt")); err != nil {
+		t.Errorf("expected unknown files to be kept: %v", err)
+	}
+
+	for _, path := range []string{filepath.Join(legacyHome, "verman.lock"), filepath.Join(legacyHome, "cache")} {
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed, got: %v", path, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(legacyHome, "versions", "v2.7.0", "spin")); err != nil {
+		t.Errorf("expected the legacy versions directory to link to the new location: %v", err)
+	}
+
+	if pending, err := LegacyHomePending(legacyHome, dirs); err != nil || pending {
+		t.Errorf("expected no migration to be pending, got: %v (error: %v)", pending, err)
+	}
+}
@@ -0,0 +1,22 @@
+package verman
+
+import (
+	"os"
+	"strconv"
+)
+
+const (
+	// KeepArchivesEnvVar makes retaining downloaded archives in the download cache the default when set to a true value
+	KeepArchivesEnvVar = "SPIN_VERMAN_KEEP_ARCHIVES"
+)
+
+// KeepArchivesByDefault reports whether retaining downloaded archives has been enabled through the environment
+func KeepArchivesByDefault() bool {
+	return envBool(KeepArchivesEnvVar)
+}
+
+// envBool reports whether the named environment variable is set to a true value (e.g. "1" or "true")
+func envBool(name string) bool {
+	enabled, err := strconv.ParseBool(os.Getenv(name))
+	return err == nil && enabled
+}
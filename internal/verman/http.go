@@ -0,0 +1,266 @@
+package verman
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// GitHubTokenEnvVar and GitHubTokenFallbackEnvVar hold a GitHub token used to authenticate requests to GitHub,
+	// which raises the API rate limit. When neither is set, the token of the GitHub CLI ("gh auth token") is used.
+	GitHubTokenEnvVar         = "GH_TOKEN"
+	GitHubTokenFallbackEnvVar = "GITHUB_TOKEN"
+
+	// HTTPTimeoutEnvVar overrides how long to wait to connect to a server and for it to start responding (e.g. "1m")
+	HTTPTimeoutEnvVar = "SPIN_VERMAN_HTTP_TIMEOUT"
+
+	// HTTPRetriesEnvVar overrides how many times a failed request is retried
+	HTTPRetriesEnvVar = "SPIN_VERMAN_HTTP_RETRIES"
+
+	DefaultHTTPTimeout = 30 * time.Second
+	DefaultHTTPRetries = 3
+
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 30 * time.Second
+	ghTokenTimeout = 5 * time.Second
+)
+
+// githubHosts are the hosts that the GitHub token is sent to. Release downloads redirect to other hosts, which the
+// token is never sent to.
+var githubHosts = map[string]bool{"api.github.com": true, "github.com": true}
+
+// HTTPClientOptions configure the client returned by NewHTTPClient
+type HTTPClientOptions struct {
+	Timeout time.Duration // How long to wait to connect and for response headers; downloads themselves aren't limited
+	Retries int           // How many times a request that failed with a network error or a server error is retried
+
+	// HTTP configures the proxy, CAs and client certificates used to connect to servers
+	HTTP HTTPConfig
+
+	// GitHubToken returns the token to authenticate requests to GitHub with, or an empty string. It is called at
+	// most once, when the first request is made to GitHub.
+	GitHubToken func() string
+
+	// OnRetry is called before a failed request is retried
+	OnRetry func(req *http.Request, err error, delay time.Duration)
+}
+
+// HTTPTimeoutFromEnv returns the HTTP timeout configured through the environment, or DefaultHTTPTimeout
+func HTTPTimeoutFromEnv() time.Duration {
+	timeout, err := time.ParseDuration(os.Getenv(HTTPTimeoutEnvVar))
+	if err != nil || timeout <= 0 {
+		return DefaultHTTPTimeout
+	}
+
+	return timeout
+}
+
+// HTTPRetriesFromEnv returns the number of HTTP retries configured through the environment, or DefaultHTTPRetries
+func HTTPRetriesFromEnv() int {
+	retries, err := strconv.Atoi(os.Getenv(HTTPRetriesEnvVar))
+	if err != nil || retries < 0 {
+		return DefaultHTTPRetries
+	}
+
+	return retries
+}
+
+// NewHTTPClient returns the client verman makes every request with. Connections use the proxy, CAs and client
+// certificates of options.HTTP, requests to GitHub are authenticated with the GitHub token, and requests that fail with
+// a network error, a server error or a 429 response are retried with exponential backoff. "file://" URLs are read from
+// the local filesystem, so assets from a local release source are downloaded (and resumed) the same way as remote ones.
+func NewHTTPClient(options HTTPClientOptions) (*http.Client, error) {
+	transport, err := newTransport(options.HTTP.HTTPHostConfig, options.HTTP.NoProxy, options.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var roundTripper http.RoundTripper = transport
+
+	if len(options.HTTP.Hosts) > 0 {
+		hosts := map[string]http.RoundTripper{}
+		for host, hostConfig := range options.HTTP.Hosts {
+			hostRoundTripper, err := newTransport(mergeHostConfig(options.HTTP.HTTPHostConfig, hostConfig), options.HTTP.NoProxy, options.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid configuration for host %s: %v", host, err)
+			}
+			hosts[strings.ToLower(host)] = hostRoundTripper
+		}
+
+		roundTripper = &hostTransport{base: roundTripper, hosts: hosts}
+	}
+
+	if options.GitHubToken != nil {
+		roundTripper = &githubAuthTransport{base: roundTripper, token: options.GitHubToken}
+	}
+
+	return &http.Client{Transport: &retryTransport{base: roundTripper, retries: options.Retries, onRetry: options.OnRetry}}, nil
+}
+
+// GitHubToken returns the GitHub token from $GH_TOKEN or $GITHUB_TOKEN, falling back to the token of the GitHub CLI if
+// it is installed and logged in. It returns an empty string if there is no token.
+func GitHubToken() string {
+	for _, envVar := range []string{GitHubTokenEnvVar, GitHubTokenFallbackEnvVar} {
+		if token := strings.TrimSpace(os.Getenv(envVar)); token != "" {
+			return token
+		}
+	}
+
+	gh, err := exec.LookPath("gh")
+	if err != nil {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ghTokenTimeout)
+	defer cancel()
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, gh, "auth", "token", "--hostname", "github.com")
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(stdout.String())
+}
+
+// githubAuthTransport adds the GitHub token to requests made to GitHub
+type githubAuthTransport struct {
+	base http.RoundTripper
+
+	token     func() string
+	tokenOnce sync.Once
+	value     string
+}
+
+func (t *githubAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !githubHosts[req.URL.Hostname()] || req.Header.Get("Authorization") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	t.tokenOnce.Do(func() { t.value = t.token() })
+	if t.value == "" {
+		return t.base.RoundTrip(req)
+	}
+
+	// A RoundTripper mustn't modify the caller's request
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.value)
+
+	return t.base.RoundTrip(req)
+}
+
+// retryTransport retries requests that fail with a network error, a server error or a 429 response. Only requests
+// without a body are retried, which covers every request verman makes.
+type retryTransport struct {
+	base    http.RoundTripper
+	retries int
+	onRetry func(req *http.Request, err error, delay time.Duration)
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+
+		if attempt >= t.retries || req.Body != nil && req.Body != http.NoBody || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		delay := retryDelay(attempt, resp)
+		if delay > retryMaxDelay {
+			return resp, err
+		}
+
+		if err == nil {
+			err = fmt.Errorf("status %d", resp.StatusCode)
+			resp.Body.Close()
+		}
+
+		if t.onRetry != nil {
+			t.onRetry(req, err, delay)
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// shouldRetry indicates whether a request that returned resp or err may succeed if it is made again
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return !isRateLimited(resp)
+	default:
+		return false
+	}
+}
+
+// retryDelay returns how long to wait before retrying: the server's Retry-After if it sent one, otherwise an
+// exponentially increasing delay with jitter
+func retryDelay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+
+	delay := retryBaseDelay << attempt
+	return delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+}
+
+// isRateLimited indicates whether resp was rejected because the GitHub API rate limit has been exhausted
+func isRateLimited(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+
+	return resp.Header.Get("X-RateLimit-Remaining") == "0"
+}
+
+// RateLimitError returns an error explaining when the GitHub API rate limit resets if resp was rejected because it has
+// been exhausted, or nil otherwise
+func RateLimitError(resp *http.Response) error {
+	if !isRateLimited(resp) {
+		return nil
+	}
+
+	message := "the GitHub API rate limit has been exceeded"
+
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		resetAt := time.Unix(reset, 0)
+		message += fmt.Sprintf("; it resets at %s (in %s)", resetAt.Local().Format(time.Kitchen), time.Until(resetAt).Round(time.Second))
+	}
+
+	if resp.Request == nil || resp.Request.Header.Get("Authorization") == "" {
+		message += fmt.Sprintf(". Set $%s or $%s, or log in with \"gh auth login\", to raise the limit", GitHubTokenEnvVar, GitHubTokenFallbackEnvVar)
+	}
+
+	return errors.New(message)
+}
+
+// StatusError describes an unsuccessful response, explaining when the GitHub API rate limit resets if that is why the
+// request failed
+func StatusError(resp *http.Response, message string) error {
+	if err := RateLimitError(resp); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("%s (status %d)", message, resp.StatusCode)
+}
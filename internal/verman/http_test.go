@@ -0,0 +1,194 @@
+package verman
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPClientRetries(t *testing.T) {
+	tests := []struct {
+		name             string
+		statuses         []int
+		retries          int
+		expectedStatus   int
+		expectedAttempts int
+	}{
+		{
+			name:             "Server errors are retried",
+			statuses:         []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			retries:          3,
+			expectedStatus:   http.StatusOK,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "Retries are limited",
+			statuses:         []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+			retries:          1,
+			expectedStatus:   http.StatusServiceUnavailable,
+			expectedAttempts: 2,
+		},
+		{
+			name:             "Client errors aren't retried",
+			statuses:         []int{http.StatusNotFound, http.StatusOK},
+			retries:          3,
+			expectedStatus:   http.StatusNotFound,
+			expectedAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// Retry-After keeps the test from waiting for the backoff
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(tt.statuses[attempts])
+				attempts++
+			}))
+			defer server.Close()
+
+			retried := 0
+			client, err := NewHTTPClient(HTTPClientOptions{
+				Timeout: time.Second,
+				Retries: tt.retries,
+				OnRetry: func(*http.Request, error, time.Duration) { retried++ },
+			})
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus || attempts != tt.expectedAttempts || retried != tt.expectedAttempts-1 {
+				t.Errorf("expected status %d after %d attempts, got: status %d after %d attempts (%d retries reported)", tt.expectedStatus, tt.expectedAttempts, resp.StatusCode, attempts, retried)
+			}
+		})
+	}
+}
+
+func TestRateLimitError(t *testing.T) {
+	reset := time.Now().Add(10 * time.Minute)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/limited" {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", fmt.Sprint(reset.Unix()))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	attempts := 0
+	client, err := NewHTTPClient(HTTPClientOptions{Retries: 3, OnRetry: func(*http.Request, error, time.Duration) { attempts++ }})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(server.URL + "/limited")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if attempts != 0 {
+		t.Errorf("expected a rate limited request not to be retried, got: %d retries", attempts)
+	}
+
+	err = StatusError(resp, "unable to list releases")
+	if err == nil || !strings.Contains(err.Error(), "rate limit") || !strings.Contains(err.Error(), reset.Local().Format(time.Kitchen)) || !strings.Contains(err.Error(), GitHubTokenEnvVar) {
+		t.Errorf("expected a rate limit error with the reset time and how to authenticate, got: %v", err)
+	}
+
+	resp, err = client.Get(server.URL + "/forbidden")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if err := StatusError(resp, "unable to list releases"); err == nil || err.Error() != "unable to list releases (status 403)" {
+		t.Errorf("expected a plain status error, got: %v", err)
+	}
+}
+
+// roundTripFunc adapts a function to an http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestGitHubAuthTransport(t *testing.T) {
+	tokenLookups := 0
+	var authorization string
+
+	transport := &githubAuthTransport{
+		base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			authorization = req.Header.Get("Authorization")
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+		}),
+		token: func() string {
+			tokenLookups++
+			return "secret"
+		},
+	}
+
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{url: "https://api.github.com/repos/fermyon/spin/releases", expected: "Bearer secret"},
+		{url: "https://github.com/fermyon/spin/releases/download/v2.7.0/spin.tar.gz", expected: "Bearer secret"},
+		{url: "https://objects.githubusercontent.com/spin.tar.gz", expected: ""},
+		{url: "https://mirror.internal/spin/releases.json", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			if _, err := transport.RoundTrip(req); err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+
+			if authorization != tt.expected {
+				t.Errorf("expected Authorization: %q, got: %q", tt.expected, authorization)
+			}
+			if req.Header.Get("Authorization") != "" {
+				t.Errorf("expected the caller's request not to be modified")
+			}
+		})
+	}
+
+	if tokenLookups != 1 {
+		t.Errorf("expected the token to be looked up once, got: %d lookups", tokenLookups)
+	}
+}
+
+func TestGitHubToken(t *testing.T) {
+	t.Setenv(GitHubTokenEnvVar, "")
+	t.Setenv(GitHubTokenFallbackEnvVar, "fallback")
+
+	if token := GitHubToken(); token != "fallback" {
+		t.Errorf("expected token from $%s, got: %q", GitHubTokenFallbackEnvVar, token)
+	}
+
+	t.Setenv(GitHubTokenEnvVar, "primary")
+
+	if token := GitHubToken(); token != "primary" {
+		t.Errorf("expected token from $%s, got: %q", GitHubTokenEnvVar, token)
+	}
+}
@@ -0,0 +1,333 @@
+package verman
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHTTPConfigFromEnv(t *testing.T) {
+	t.Setenv(ProxyEnvVar, "http://proxy.internal:3128")
+	t.Setenv(CAFilesEnvVar, "/etc/ca/one.pem"+string(os.PathListSeparator)+"/etc/ca/two.pem")
+	t.Setenv(ClientCertEnvVar, "/etc/client.pem")
+	t.Setenv(ClientKeyEnvVar, "")
+	t.Setenv("NO_PROXY", "localhost,.internal")
+
+	config := HTTPConfigFromEnv(HTTPConfig{
+		HTTPHostConfig: HTTPHostConfig{Proxy: "http://other:3128", CAFiles: []string{"/etc/ca/config.pem"}, ClientCert: "/etc/other.pem", ClientKey: "/etc/other.key"},
+	})
+
+	expected := HTTPConfig{
+		HTTPHostConfig: HTTPHostConfig{
+			Proxy:      "http://proxy.internal:3128",
+			CAFiles:    []string{"/etc/ca/config.pem", "/etc/ca/one.pem", "/etc/ca/two.pem"},
+			ClientCert: "/etc/client.pem",
+		},
+		NoProxy: []string{"localhost", ".internal"},
+	}
+
+	if !reflect.DeepEqual(config, expected) {
+		t.Errorf("expected config: %+v, got: %+v", expected, config)
+	}
+}
+
+func TestMatchesNoProxy(t *testing.T) {
+	noProxy := []string{"localhost", ".internal", "example.com:443"}
+
+	tests := []struct {
+		host     string
+		expected bool
+	}{
+		{host: "localhost", expected: true},
+		{host: "mirror.internal", expected: true},
+		{host: "internal", expected: true},
+		{host: "example.com", expected: true},
+		{host: "downloads.example.com", expected: true},
+		{host: "github.com", expected: false},
+		{host: "notexample.com", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if matchesNoProxy(tt.host, noProxy) != tt.expected {
+				t.Errorf("expected %s to match: %v", tt.host, tt.expected)
+			}
+		})
+	}
+
+	if !matchesNoProxy("github.com", []string{"*"}) {
+		t.Errorf("expected * to match every host")
+	}
+}
+
+func TestFilePathFromURL(t *testing.T) {
+	tests := []struct {
+		urlPath  string
+		expected string
+	}{
+		{urlPath: "/srv/mirror/v2.7.0/spin.tar.gz", expected: filepath.FromSlash("/srv/mirror/v2.7.0/spin.tar.gz")},
+		{urlPath: "/C:/mirror/v2.7.0/spin.zip", expected: filepath.FromSlash("C:/mirror/v2.7.0/spin.zip")},
+		{urlPath: "/d:/spin.zip", expected: filepath.FromSlash("d:/spin.zip")},
+		{urlPath: "/1:/spin.zip", expected: filepath.FromSlash("/1:/spin.zip")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.urlPath, func(t *testing.T) {
+			if filePath := filePathFromURL(tt.urlPath); filePath != tt.expected {
+				t.Errorf("expected: %s, got: %s", tt.expected, filePath)
+			}
+		})
+	}
+}
+
+func TestFileTransport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "v2.7.0")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "spin.tar.gz"), []byte("spin archive"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	client := &http.Client{Transport: fileTransport{}}
+	source := &LocalSource{Dir: filepath.Dir(dir)}
+
+	req, err := http.NewRequest(http.MethodGet, source.AssetURL("v2.7.0", "spin.tar.gz"), nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	// Interrupted downloads are resumed with a range request
+	req.Header.Set("Range", "bytes=5-")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusPartialContent || string(body) != "archive" {
+		t.Errorf("expected partial content \"archive\", got: %d %q", resp.StatusCode, body)
+	}
+
+	resp, err = client.Get(source.AssetURL("v2.7.0", "missing.tar.gz"))
+	if err != nil {
+		t.Fatalf("failed to request missing file: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a missing file to be not found, got: %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPClientProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A proxied request has the absolute URL of its target
+		fmt.Fprintf(w, "proxied %s", r.URL.Host)
+	}))
+	defer proxy.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "direct")
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		config   HTTPConfig
+		url      string
+		expected string
+	}{
+		{
+			name:     "Proxy",
+			config:   HTTPConfig{HTTPHostConfig: HTTPHostConfig{Proxy: proxy.URL}},
+			url:      "http://releases.internal/releases.json",
+			expected: "proxied releases.internal",
+		},
+		{
+			name:     "No proxy",
+			config:   HTTPConfig{HTTPHostConfig: HTTPHostConfig{Proxy: proxy.URL}, NoProxy: []string{"127.0.0.1"}},
+			url:      server.URL,
+			expected: "direct",
+		},
+		{
+			name:     "Host without the proxy",
+			config:   HTTPConfig{HTTPHostConfig: HTTPHostConfig{Proxy: proxy.URL}, Hosts: map[string]HTTPHostConfig{"127.0.0.1": {Proxy: ProxyDirect}}},
+			url:      server.URL,
+			expected: "direct",
+		},
+		{
+			name:     "Host with its own proxy",
+			config:   HTTPConfig{Hosts: map[string]HTTPHostConfig{"releases.internal": {Proxy: proxy.URL}}},
+			url:      "http://releases.internal/releases.json",
+			expected: "proxied releases.internal",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewHTTPClient(HTTPClientOptions{HTTP: tt.config})
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			if body := getBody(t, client, tt.url); body != tt.expected {
+				t.Errorf("expected response: %q, got: %q", tt.expected, body)
+			}
+		})
+	}
+
+	if _, err := NewHTTPClient(HTTPClientOptions{HTTP: HTTPConfig{HTTPHostConfig: HTTPHostConfig{Proxy: "proxy.internal"}}}); err == nil {
+		t.Errorf("expected an error for a proxy without a scheme")
+	}
+}
+
+func TestHTTPClientTLS(t *testing.T) {
+	dir := t.TempDir()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			fmt.Fprint(w, "anonymous")
+			return
+		}
+
+		fmt.Fprint(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0644); err != nil {
+		t.Fatalf("failed to write CA file: %v", err)
+	}
+
+	clientCert := writeClientCertificate(t, dir, "verman")
+
+	invalidCAFile := filepath.Join(dir, "invalid.pem")
+	if err := os.WriteFile(invalidCAFile, []byte("not a certificate"), 0644); err != nil {
+		t.Fatalf("failed to write CA file: %v", err)
+	}
+
+	// The server's certificate isn't trusted by default
+	client, err := NewHTTPClient(HTTPClientOptions{})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if resp, err := client.Get(server.URL); err == nil {
+		resp.Body.Close()
+		t.Errorf("expected the server's certificate not to be trusted")
+	}
+
+	tests := []struct {
+		name     string
+		config   HTTPConfig
+		expected string
+	}{
+		{
+			name:     "CA file",
+			config:   HTTPConfig{HTTPHostConfig: HTTPHostConfig{CAFiles: []string{caFile}}},
+			expected: "anonymous",
+		},
+		{
+			name:     "Client certificate",
+			config:   HTTPConfig{HTTPHostConfig: HTTPHostConfig{CAFiles: []string{caFile}, ClientCert: clientCert}},
+			expected: "verman",
+		},
+		{
+			name:     "Host configuration",
+			config:   HTTPConfig{Hosts: map[string]HTTPHostConfig{"127.0.0.1": {CAFiles: []string{caFile}, ClientCert: clientCert}}},
+			expected: "verman",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewHTTPClient(HTTPClientOptions{HTTP: tt.config})
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			if body := getBody(t, client, server.URL); body != tt.expected {
+				t.Errorf("expected response: %q, got: %q", tt.expected, body)
+			}
+		})
+	}
+
+	for _, config := range []HTTPHostConfig{{CAFiles: []string{invalidCAFile}}, {CAFiles: []string{filepath.Join(dir, "missing.pem")}}, {ClientCert: caFile}} {
+		if _, err := NewHTTPClient(HTTPClientOptions{HTTP: HTTPConfig{HTTPHostConfig: config}}); err == nil {
+			t.Errorf("expected an error for invalid TLS configuration %+v", config)
+		}
+	}
+}
+
+// getBody makes a GET request and returns the response body
+func getBody(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+
+	return string(body)
+}
+
+// writeClientCertificate writes a self-signed client certificate and its key to a single PEM file
+func writeClientCertificate(t *testing.T, dir, commonName string) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	content := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate})
+	content = append(content, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})...)
+
+	certFile := filepath.Join(dir, commonName+".pem")
+	if err := os.WriteFile(certFile, content, 0600); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+
+	return certFile
+}
@@ -0,0 +1,177 @@
+package verman
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Linker makes an installed Spin binary available at another path, such as "current_version/spin" or an alias
+type Linker interface {
+	// Link replaces the file at linkPath with a link to target
+	Link(target, linkPath string) error
+
+	// Target returns the path that linkPath links to
+	Target(linkPath string) (string, error)
+
+	// Remove removes the link at linkPath. A missing link isn't an error.
+	Remove(linkPath string) error
+
+	// Refresh re-links linkPath if the binary it links to has been replaced since it was linked
+	Refresh(linkPath string) error
+}
+
+// NewLinker returns the Linker for a Go OS: symlinks everywhere except on Windows, where creating them requires
+// elevated privileges or developer mode
+func NewLinker(goos string) Linker {
+	if goos == "windows" {
+		return CopyLinker{}
+	}
+
+	return SymlinkLinker{}
+}
+
+// SymlinkLinker links binaries with symlinks
+type SymlinkLinker struct{}
+
+func (SymlinkLinker) Link(target, linkPath string) error {
+	if err := (SymlinkLinker{}).Remove(linkPath); err != nil {
+		return err
+	}
+
+	return os.Symlink(target, linkPath)
+}
+
+func (SymlinkLinker) Target(linkPath string) (string, error) {
+	return os.Readlink(linkPath)
+}
+
+func (SymlinkLinker) Remove(linkPath string) error {
+	if err := os.Remove(linkPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove old symlink: %v", err)
+	}
+
+	return nil
+}
+
+// Refresh does nothing, as a symlink always resolves to the current binary at its target
+func (SymlinkLinker) Refresh(linkPath string) error {
+	return nil
+}
+
+// CopyLinker links binaries with hard links, falling back to copies when the target is on another volume. As neither
+// records where it came from, the target is written to a hidden file next to the link.
+type CopyLinker struct{}
+
+func (CopyLinker) Link(target, linkPath string) error {
+	if err := (CopyLinker{}).Remove(linkPath); err != nil {
+		return err
+	}
+
+	if err := os.Link(target, linkPath); err != nil {
+		if err := CopyFile(target, linkPath); err != nil {
+			return err
+		}
+	}
+
+	return os.WriteFile(linkTargetPath(linkPath), []byte(target+"\n"), 0644)
+}
+
+// Target falls back to reading a symlink, which may have been created by an older release of verman
+func (CopyLinker) Target(linkPath string) (string, error) {
+	content, err := os.ReadFile(linkTargetPath(linkPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return os.Readlink(linkPath)
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(string(content)), nil
+}
+
+func (CopyLinker) Remove(linkPath string) error {
+	for _, file := range []string{linkPath, linkTargetPath(linkPath)} {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove old link: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Refresh is needed because a hard link or copy is a snapshot: rebuilding the target (e.g. "cargo build" replacing
+// "target/release/spin") leaves the link with the old binary. A link created by an older release of verman, without a
+// recorded target, is left as it is.
+func (CopyLinker) Refresh(linkPath string) error {
+	content, err := os.ReadFile(linkTargetPath(linkPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	target := strings.TrimSpace(string(content))
+
+	targetInfo, err := os.Stat(target)
+	if err != nil {
+		return fmt.Errorf("failed to find the linked binary %s: %v", target, err)
+	}
+
+	linkInfo, err := os.Stat(linkPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// A hard link is current while it is the same file. A copy is current unless the target changed after it was made.
+	if linkInfo != nil && (os.SameFile(linkInfo, targetInfo) ||
+		(linkInfo.Size() == targetInfo.Size() && !linkInfo.ModTime().Before(targetInfo.ModTime()))) {
+		return nil
+	}
+
+	return (CopyLinker{}).Link(target, linkPath)
+}
+
+// linkTargetPath returns the path of the file recording the target of a CopyLinker link
+func linkTargetPath(linkPath string) string {
+	return filepath.Join(filepath.Dir(linkPath), "."+filepath.Base(linkPath)+".target")
+}
+
+// CopyFile copies src to dst with the same permissions. The copy is written to a temporary file first so that an
+// interrupted copy never leaves a truncated binary at dst.
+func CopyFile(src, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	info, err := srcFile.Stat()
+	if err != nil {
+		return err
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := io.Copy(tempFile, srcFile); err != nil {
+		tempFile.Close()
+		return err
+	}
+
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tempFile.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), dst)
+}
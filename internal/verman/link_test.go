@@ -0,0 +1,215 @@
+package verman
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLinkers(t *testing.T) {
+	tests := []struct {
+		name   string
+		linker Linker
+	}{
+		{name: "Symlink", linker: NewLinker("linux")},
+		{name: "Copy", linker: NewLinker("windows")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			var targets []string
+			for _, version := range []string{"v2.6.0", "v2.7.0"} {
+				target := filepath.Join(dir, version, "spin")
+				if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+					t.Fatalf("failed to create version directory: %v", err)
+				}
+				if err := os.WriteFile(target, []byte(version), 0755); err != nil {
+					t.Fatalf("failed to write binary: %v", err)
+				}
+				targets = append(targets, target)
+			}
+
+			linkPath := filepath.Join(dir, "spin")
+
+			// Linking again replaces the previous link
+			for _, target := range targets {
+				if err := tt.linker.Link(target, linkPath); err != nil {
+					t.Fatalf("failed to link %s: %v", target, err)
+				}
+
+				if linked, err := tt.linker.Target(linkPath); err != nil || linked != target {
+					t.Errorf("expected target: %s, got: %s (error: %v)", target, linked, err)
+				}
+
+				content, err := os.ReadFile(linkPath)
+				if err != nil || string(content) != filepath.Base(filepath.Dir(target)) {
+					t.Errorf("expected the link to have the content of %s, got: %q (error: %v)", target, content, err)
+				}
+
+				if info, err := os.Stat(linkPath); err != nil || info.Mode().Perm()&0111 == 0 {
+					t.Errorf("expected the link to be executable")
+				}
+			}
+
+			if err := tt.linker.Remove(linkPath); err != nil {
+				t.Fatalf("failed to remove link: %v", err)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("failed to read directory: %v", err)
+			}
+			if len(entries) != 2 {
+				t.Errorf("expected only the version directories to remain, got: %v", entries)
+			}
+
+			if err := tt.linker.Remove(linkPath); err != nil {
+				t.Errorf("expected removing a missing link to succeed, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestLinkerRefresh(t *testing.T) {
+	tests := []struct {
+		name   string
+		linker Linker
+		copy   bool // Replace the hard link with a copy, as when the target is on another volume
+	}{
+		{name: "Symlink", linker: NewLinker("linux")},
+		{name: "Hard link", linker: NewLinker("windows")},
+		{name: "Copy", linker: NewLinker("windows"), copy: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			target := filepath.Join(dir, "target", "release", "spin")
+			linkPath := filepath.Join(dir, "dev", "spin")
+
+			for _, path := range []string{target, linkPath} {
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatalf("failed to create directory: %v", err)
+				}
+			}
+
+			if err := os.WriteFile(target, []byte("old build"), 0755); err != nil {
+				t.Fatalf("failed to write binary: %v", err)
+			}
+
+			if err := tt.linker.Link(target, linkPath); err != nil {
+				t.Fatalf("failed to link: %v", err)
+			}
+
+			if tt.copy {
+				if err := CopyFile(target, linkPath); err != nil {
+					t.Fatalf("failed to copy binary: %v", err)
+				}
+			}
+
+			// An unchanged target isn't linked again
+			before, err := os.Stat(linkPath)
+			if err != nil {
+				t.Fatalf("failed to stat link: %v", err)
+			}
+			if err := tt.linker.Refresh(linkPath); err != nil {
+				t.Fatalf("failed to refresh link: %v", err)
+			}
+			if after, err := os.Stat(linkPath); err != nil || !os.SameFile(before, after) {
+				t.Errorf("expected an unchanged target to keep the link, got: %v", err)
+			}
+
+			// Builds replace the binary rather than writing to it, so a hard link keeps the old one
+			rebuilt := target + ".new"
+			if err := os.WriteFile(rebuilt, []byte("new build"), 0755); err != nil {
+				t.Fatalf("failed to write binary: %v", err)
+			}
+			later := time.Now().Add(time.Minute)
+			if err := os.Chtimes(rebuilt, later, later); err != nil {
+				t.Fatalf("failed to set modification time: %v", err)
+			}
+			if err := os.Rename(rebuilt, target); err != nil {
+				t.Fatalf("failed to replace binary: %v", err)
+			}
+
+			if err := tt.linker.Refresh(linkPath); err != nil {
+				t.Fatalf("failed to refresh link: %v", err)
+			}
+
+			if content, err := os.ReadFile(linkPath); err != nil || string(content) != "new build" {
+				t.Errorf("expected the rebuilt binary, got: %q (error: %v)", content, err)
+			}
+
+			if linked, err := tt.linker.Target(linkPath); err != nil || linked != target {
+				t.Errorf("expected target: %s, got: %s (error: %v)", target, linked, err)
+			}
+		})
+	}
+}
+
+func TestCopyLinkerRefreshMissingTarget(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "spin")
+	linkPath := filepath.Join(dir, "dev", "spin")
+
+	if err := os.MkdirAll(filepath.Dir(linkPath), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(target, []byte("build"), 0755); err != nil {
+		t.Fatalf("failed to write binary: %v", err)
+	}
+	if err := (CopyLinker{}).Link(target, linkPath); err != nil {
+		t.Fatalf("failed to link: %v", err)
+	}
+	if err := os.Remove(target); err != nil {
+		t.Fatalf("failed to remove binary: %v", err)
+	}
+
+	if err := (CopyLinker{}).Refresh(linkPath); err == nil {
+		t.Errorf("expected an error for a missing target")
+	}
+}
+
+func TestCopyLinkerReadsSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	linkPath := filepath.Join(dir, "spin.exe")
+
+	if err := os.Symlink(filepath.Join(dir, "v2.7.0", "spin.exe"), linkPath); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	if target, err := (CopyLinker{}).Target(linkPath); err != nil || target != filepath.Join(dir, "v2.7.0", "spin.exe") {
+		t.Errorf("expected the symlink's target, got: %s (error: %v)", target, err)
+	}
+}
+
+func TestCopyFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+
+	if err := os.WriteFile(src, []byte("binary"), 0755); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	// Copies are used when hard links fail, e.g. across volumes
+	dst := filepath.Join(dir, "dst")
+	if err := CopyFile(src, dst); err != nil {
+		t.Fatalf("failed to copy file: %v", err)
+	}
+
+	info, err := os.Stat(dst)
+	if err != nil || info.Mode().Perm() != 0755 {
+		t.Fatalf("expected an executable copy, got: %v (error: %v)", info, err)
+	}
+
+	if content, err := os.ReadFile(dst); err != nil || string(content) != "binary" {
+		t.Errorf("expected the content of the source, got: %q (error: %v)", content, err)
+	}
+
+	if srcInfo, err := os.Stat(src); err != nil || os.SameFile(info, srcInfo) {
+		t.Errorf("expected a copy rather than a link")
+	}
+}
@@ -0,0 +1,206 @@
+package verman
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// LockTimeoutEnvVar overrides how long to wait for another verman process to release its lock (e.g. "30s" or "10m")
+	LockTimeoutEnvVar = "SPIN_VERMAN_LOCK_TIMEOUT"
+	// DefaultLockTimeout is how long to wait for another verman process to release its lock by default
+	DefaultLockTimeout = 5 * time.Minute
+
+	lockPollInterval = 100 * time.Millisecond
+	// fallbackLockSuffix names the exclusively-created lock file used where advisory locks aren't supported
+	fallbackLockSuffix = ".pid"
+	// takeoverSuffix names the exclusively-created marker held by the one process removing a stale fallback lock
+	takeoverSuffix = ".takeover"
+	// staleTakeoverAge is how old a takeover marker must be before it is assumed to have been left by a crashed process
+	staleTakeoverAge = 10 * time.Second
+)
+
+// errLockUnsupported is returned by tryLockFile when the filesystem doesn't support advisory locks
+var errLockUnsupported = errors.New("advisory locks are not supported")
+
+// errLockHeld is returned by tryLockFile when another process holds the lock
+var errLockHeld = errors.New("lock is held by another process")
+
+// Lock is an advisory, cross-process lock held on a lock file. The holder's PID is written to the lock file so that
+// waiting processes can report who they are waiting for.
+type Lock struct {
+	file *os.File
+	path string
+}
+
+// LockTimeoutFromEnv returns the lock timeout configured through the environment, or DefaultLockTimeout
+func LockTimeoutFromEnv() time.Duration {
+	timeout, err := time.ParseDuration(os.Getenv(LockTimeoutEnvVar))
+	if err != nil || timeout <= 0 {
+		return DefaultLockTimeout
+	}
+
+	return timeout
+}
+
+// AcquireLock acquires the lock at lockPath, waiting up to timeout for another process to release it. The first time it
+// has to wait, onWait is called with the PID of the process holding the lock (or 0 if it is unknown).
+// An flock(2) lock is used where it is supported, falling back to an exclusively-created PID file elsewhere.
+func AcquireLock(lockPath string, timeout time.Duration, onWait func(pid int)) (*Lock, error) {
+	file, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	waiting := false
+
+	for {
+		err := tryLockFile(file)
+		if err == nil {
+			break
+		}
+
+		if errors.Is(err, errLockUnsupported) {
+			file.Close()
+			return acquireFallbackLock(lockPath, deadline, onWait)
+		}
+
+		if !errors.Is(err, errLockHeld) {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock %s: %v", lockPath, err)
+		}
+
+		if err := waitForLock(lockPath, deadline, &waiting, onWait); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	// Record the holder's PID for waiting processes
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+
+	return &Lock{file: file, path: lockPath}, nil
+}
+
+// acquireFallbackLock acquires the lock by exclusively creating a PID file, removing it if the process that created it has exited
+func acquireFallbackLock(lockPath string, deadline time.Time, onWait func(pid int)) (*Lock, error) {
+	pidPath := lockPath + fallbackLockSuffix
+	waiting := false
+
+	for {
+		file, err := os.OpenFile(pidPath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			if _, err := file.WriteString(strconv.Itoa(os.Getpid()) + "\n"); err != nil {
+				file.Close()
+				os.Remove(pidPath)
+				return nil, err
+			}
+
+			return &Lock{file: file, path: pidPath}, nil
+		}
+
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to lock %s: %v", pidPath, err)
+		}
+
+		// A lock left behind by a process that crashed is removed rather than waited for
+		if pid := readLockHolder(pidPath); pid > 0 && !processAlive(pid) {
+			removeStaleLock(pidPath, pid)
+			continue
+		}
+
+		if err := waitForLock(pidPath, deadline, &waiting, onWait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// removeStaleLock removes a PID file left behind by stalePID. Only the process that exclusively creates the takeover
+// marker may remove it, and it checks the PID again while holding the marker: a new lock can only be created once the
+// stale file is gone, so a lock that is still held by stalePID then can't have been replaced by a live one.
+func removeStaleLock(pidPath string, stalePID int) {
+	markerPath := pidPath + takeoverSuffix
+
+	marker, err := os.OpenFile(markerPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		// A marker left behind by a process that crashed while taking over the lock would block every other process
+		if info, statErr := os.Stat(markerPath); statErr == nil && time.Since(info.ModTime()) > staleTakeoverAge {
+			os.Remove(markerPath)
+		}
+		return
+	}
+	defer os.Remove(markerPath)
+	defer marker.Close()
+
+	if readLockHolder(pidPath) == stalePID {
+		os.Remove(pidPath)
+	}
+}
+
+// waitForLock sleeps before the next attempt to acquire the lock, or returns an error once the deadline has passed
+func waitForLock(lockPath string, deadline time.Time, waiting *bool, onWait func(pid int)) error {
+	pid := readLockHolder(lockPath)
+
+	if time.Now().After(deadline) {
+		if pid > 0 {
+			return fmt.Errorf("timed out waiting for lock %s held by PID %d", lockPath, pid)
+		}
+
+		return fmt.Errorf("timed out waiting for lock %s", lockPath)
+	}
+
+	if !*waiting {
+		*waiting = true
+		if onWait != nil {
+			onWait(pid)
+		}
+	}
+
+	time.Sleep(lockPollInterval)
+	return nil
+}
+
+// readLockHolder returns the PID recorded in a lock file, or 0 if there is none
+func readLockHolder(lockPath string) int {
+	content, err := os.ReadFile(lockPath)
+	if err != nil {
+		return 0
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return 0
+	}
+
+	return pid
+}
+
+// Release releases the lock
+func (l *Lock) Release() error {
+	if strings.HasSuffix(l.path, fallbackLockSuffix) {
+		info, err := l.file.Stat()
+		l.file.Close()
+		if err != nil {
+			return err
+		}
+
+		// The lock file is only removed if it is still this lock's, and not one created by another process after this
+		// one was taken over
+		if current, err := os.Stat(l.path); err != nil || !os.SameFile(info, current) {
+			return nil
+		}
+
+		return os.Remove(l.path)
+	}
+
+	// Closing the file releases the flock
+	l.file.Truncate(0)
+	return l.file.Close()
+}
@@ -0,0 +1,22 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package verman
+
+import "os"
+
+// tryLockFile always reports that advisory locks are unsupported, so the PID file fallback is used
+func tryLockFile(file *os.File) error {
+	return errLockUnsupported
+}
+
+// processAlive indicates whether a process with the given PID is running
+func processAlive(pid int) bool {
+	// On Windows, FindProcess opens a handle to the process and fails if it doesn't exist
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	process.Release()
+	return true
+}
@@ -0,0 +1,213 @@
+package verman
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAcquireLock(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), "verman.lock")
+
+	lock, err := AcquireLock(lockPath, time.Second, nil)
+	if err != nil {
+		t.Fatalf("failed to acquire lock: %v", err)
+	}
+
+	waitedFor := -1
+	_, err = AcquireLock(lockPath, 300*time.Millisecond, func(pid int) { waitedFor = pid })
+	if err == nil {
+		t.Fatalf("expected acquiring a held lock to time out")
+	}
+	if waitedFor != os.Getpid() {
+		t.Errorf("expected to wait for PID %d, got: %d", os.Getpid(), waitedFor)
+	}
+	if !strings.Contains(err.Error(), strconv.Itoa(os.Getpid())) {
+		t.Errorf("expected timeout error to name the holder's PID, got: %v", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("failed to release lock: %v", err)
+	}
+
+	lock, err = AcquireLock(lockPath, time.Second, nil)
+	if err != nil {
+		t.Fatalf("failed to acquire released lock: %v", err)
+	}
+	lock.Release()
+}
+
+func TestAcquireFallbackLockRemovesStaleLock(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), "verman.lock")
+
+	// This is above the maximum PID on Linux and macOS, so it can't belong to a running process
+	if err := os.WriteFile(lockPath+fallbackLockSuffix, []byte("2147483647\n"), 0644); err != nil {
+		t.Fatalf("failed to write stale lock: %v", err)
+	}
+
+	lock, err := acquireFallbackLock(lockPath, time.Now().Add(time.Second), nil)
+	if err != nil {
+		t.Fatalf("expected stale lock to be replaced, got: %v", err)
+	}
+
+	if pid := readLockHolder(lockPath + fallbackLockSuffix); pid != os.Getpid() {
+		t.Errorf("expected lock to be held by PID %d, got: %d", os.Getpid(), pid)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("failed to release lock: %v", err)
+	}
+
+	if _, err := os.Stat(lockPath + fallbackLockSuffix); !os.IsNotExist(err) {
+		t.Errorf("expected fallback lock file to be removed on release")
+	}
+}
+
+func TestRemoveStaleLock(t *testing.T) {
+	tests := []struct {
+		name            string
+		content         string
+		expectedRemoved bool
+	}{
+		{name: "Stale", content: "2147483647\n", expectedRemoved: true},
+		// Another process replaced the stale lock with its own before this one took it over
+		{name: "Replaced", content: strconv.Itoa(os.Getpid()) + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			pidPath := filepath.Join(dir, "verman.lock"+fallbackLockSuffix)
+
+			if err := os.WriteFile(pidPath, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write lock: %v", err)
+			}
+
+			removeStaleLock(pidPath, 2147483647)
+
+			_, err := os.Stat(pidPath)
+			if removed := os.IsNotExist(err); removed != tt.expectedRemoved {
+				t.Errorf("expected removed: %v, got: %v (error: %v)", tt.expectedRemoved, removed, err)
+			}
+
+			if !tt.expectedRemoved && readLockHolder(pidPath) != os.Getpid() {
+				t.Errorf("expected the replaced lock to be kept")
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("failed to read lock directory: %v", err)
+			}
+			if len(entries) > 1 || (tt.expectedRemoved && len(entries) != 0) {
+				t.Errorf("expected no files to be left behind, got: %v", entries)
+			}
+		})
+	}
+}
+
+func TestRemoveStaleLockTakeoverMarker(t *testing.T) {
+	dir := t.TempDir()
+	pidPath := filepath.Join(dir, "verman.lock"+fallbackLockSuffix)
+	markerPath := pidPath + takeoverSuffix
+
+	for _, path := range []string{pidPath, markerPath} {
+		if err := os.WriteFile(path, []byte("2147483647\n"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+
+	// Another process is taking over the stale lock, so it is left to that process
+	removeStaleLock(pidPath, 2147483647)
+	if _, err := os.Stat(pidPath); err != nil {
+		t.Errorf("expected the stale lock to be left to the process taking it over: %v", err)
+	}
+	if _, err := os.Stat(markerPath); err != nil {
+		t.Errorf("expected the takeover marker to be kept: %v", err)
+	}
+
+	// A marker left behind by a crashed takeover is removed, so that the next attempt can take over the lock
+	old := time.Now().Add(-2 * staleTakeoverAge)
+	if err := os.Chtimes(markerPath, old, old); err != nil {
+		t.Fatalf("failed to age the takeover marker: %v", err)
+	}
+
+	removeStaleLock(pidPath, 2147483647)
+	if _, err := os.Stat(markerPath); !os.IsNotExist(err) {
+		t.Errorf("expected the abandoned takeover marker to be removed, got: %v", err)
+	}
+
+	removeStaleLock(pidPath, 2147483647)
+	if _, err := os.Stat(pidPath); !os.IsNotExist(err) {
+		t.Errorf("expected the stale lock to be removed, got: %v", err)
+	}
+}
+
+func TestAcquireFallbackLockConcurrently(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), "verman.lock")
+	pidPath := lockPath + fallbackLockSuffix
+
+	if err := os.WriteFile(pidPath, []byte("2147483647\n"), 0644); err != nil {
+		t.Fatalf("failed to write stale lock: %v", err)
+	}
+
+	const workers, rounds = 8, 10
+
+	var holders, maxHolders int32
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*rounds)
+
+	// Each worker stands for a process. Some of them crash while holding the lock, leaving a stale lock behind that the
+	// others then compete to take over.
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+
+			for round := 0; round < rounds; round++ {
+				lock, err := acquireFallbackLock(lockPath, time.Now().Add(30*time.Second), nil)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				current := atomic.AddInt32(&holders, 1)
+				for {
+					max := atomic.LoadInt32(&maxHolders)
+					if current <= max || atomic.CompareAndSwapInt32(&maxHolders, max, current) {
+						break
+					}
+				}
+
+				time.Sleep(time.Millisecond)
+				atomic.AddInt32(&holders, -1)
+
+				if (worker+round)%3 == 0 {
+					lock.file.WriteAt([]byte("2147483647\n"), 0)
+					lock.file.Close()
+					continue
+				}
+
+				if err := lock.Release(); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(worker)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("failed to acquire the lock: %v", err)
+	}
+
+	if maxHolders != 1 {
+		t.Errorf("expected the lock to be held by one worker at a time, got: %d", maxHolders)
+	}
+}
@@ -0,0 +1,114 @@
+package verman
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// VariantEnvVar overrides the default build variant of Spin that is downloaded on Linux
+	VariantEnvVar = "SPIN_VERMAN_VARIANT"
+)
+
+// The build variants of Spin. On Linux, Spin is released both dynamically linked against glibc ("linux" assets) and
+// statically linked against musl ("static-linux" assets), which also runs on musl-based distributions such as Alpine.
+const (
+	VariantAuto   = "auto"   // Detect the variant that runs on the current system
+	VariantGlibc  = "glibc"  // The "linux" assets
+	VariantStatic = "static" // The "static-linux" assets
+)
+
+// glibcLoaderPatterns match the dynamic loader of glibc, relative to the root directory
+var glibcLoaderPatterns = []string{"lib64/ld-linux-*.so.*", "lib/ld-linux-*.so.*", "lib/*/ld-linux-*.so.*"}
+
+// VariantFromEnv returns the build variant configured through the environment, or VariantAuto
+func VariantFromEnv() string {
+	if variant := os.Getenv(VariantEnvVar); variant != "" {
+		return variant
+	}
+
+	return VariantAuto
+}
+
+// SpinPlatform returns the OS and architecture names that Spin's release assets use for a Go OS and architecture, and
+// the build variant they are for. variant is VariantAuto, VariantGlibc or VariantStatic; VariantAuto is resolved with
+// detectVariant, which is only called on Linux. Other OSes have a single variant, for which an empty string is returned.
+func SpinPlatform(goos, goarch, variant string, detectVariant func() string) (spinOS, spinArch, resolvedVariant string, err error) {
+	switch goarch {
+	case "amd64":
+		spinArch = "amd64"
+	case "arm64":
+		spinArch = "aarch64"
+	default:
+		return "", "", "", fmt.Errorf("%q is not an architecture that Spin supports", goarch)
+	}
+
+	if variant != VariantAuto && variant != VariantGlibc && variant != VariantStatic {
+		return "", "", "", fmt.Errorf("unknown variant %q (expected %s, %s or %s)", variant, VariantAuto, VariantGlibc, VariantStatic)
+	}
+
+	switch goos {
+	case "linux":
+		if variant == VariantAuto {
+			variant = detectVariant()
+		}
+
+		if variant == VariantStatic {
+			return "static-linux", spinArch, variant, nil
+		}
+		return "linux", spinArch, variant, nil
+	case "darwin", "windows":
+		if variant == VariantStatic || variant == VariantGlibc {
+			return "", "", "", fmt.Errorf("the %s variant of Spin is only available for Linux", variant)
+		}
+
+		if goos == "windows" {
+			if spinArch != "amd64" {
+				return "", "", "", fmt.Errorf("Spin is not released for Windows on %q", goarch)
+			}
+			return "windows", spinArch, "", nil
+		}
+		return "macos", spinArch, "", nil
+	default:
+		return "", "", "", fmt.Errorf("%q is not an OS that this Spin plugin supports", goos)
+	}
+}
+
+// BinaryName returns the file name of the Spin binary on a Go OS
+func BinaryName(goos string) string {
+	if goos == "windows" {
+		return "spin.exe"
+	}
+
+	return "spin"
+}
+
+// SamePathName reports whether a and b name the same path on a Go OS, ignoring trailing separators. Paths on Windows
+// are case-insensitive and may use either separator. Symlinks aren't followed.
+func SamePathName(a, b, goos string) bool {
+	if goos == "windows" {
+		a, b = strings.ReplaceAll(a, "/", `\`), strings.ReplaceAll(b, "/", `\`)
+		return strings.EqualFold(strings.TrimRight(a, `\`), strings.TrimRight(b, `\`))
+	}
+
+	return a == b || strings.TrimRight(a, "/") == strings.TrimRight(b, "/")
+}
+
+// DetectLinuxVariant returns the build variant of Spin that runs on the Linux system with the given root directory:
+// VariantGlibc if it has the glibc dynamic loader, otherwise VariantStatic (on musl-based distributions, or images
+// without a libc). musl's loader takes precedence, as musl systems may provide a glibc compatibility loader.
+func DetectLinuxVariant(root string) string {
+	if matches, _ := filepath.Glob(filepath.Join(root, "lib", "ld-musl-*.so.1")); len(matches) > 0 {
+		return VariantStatic
+	}
+
+	for _, pattern := range glibcLoaderPatterns {
+		if matches, _ := filepath.Glob(filepath.Join(root, filepath.FromSlash(pattern))); len(matches) > 0 {
+			return VariantGlibc
+		}
+	}
+
+	return VariantStatic
+}
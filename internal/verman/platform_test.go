@@ -0,0 +1,111 @@
+package verman
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSpinPlatform(t *testing.T) {
+	tests := []struct {
+		name            string
+		goos            string
+		goarch          string
+		variant         string
+		detected        string
+		expectedOS      string
+		expectedArch    string
+		expectedVariant string
+		expectError     bool
+	}{
+		{name: "glibc Linux", goos: "linux", goarch: "amd64", variant: VariantAuto, detected: VariantGlibc, expectedOS: "linux", expectedArch: "amd64", expectedVariant: VariantGlibc},
+		{name: "musl Linux", goos: "linux", goarch: "arm64", variant: VariantAuto, detected: VariantStatic, expectedOS: "static-linux", expectedArch: "aarch64", expectedVariant: VariantStatic},
+		{name: "Static override", goos: "linux", goarch: "amd64", variant: VariantStatic, detected: VariantGlibc, expectedOS: "static-linux", expectedArch: "amd64", expectedVariant: VariantStatic},
+		{name: "glibc override", goos: "linux", goarch: "amd64", variant: VariantGlibc, detected: VariantStatic, expectedOS: "linux", expectedArch: "amd64", expectedVariant: VariantGlibc},
+		{name: "macOS", goos: "darwin", goarch: "arm64", variant: VariantAuto, expectedOS: "macos", expectedArch: "aarch64"},
+		{name: "Static macOS", goos: "darwin", goarch: "arm64", variant: VariantStatic, expectError: true},
+		{name: "Unknown variant", goos: "linux", goarch: "amd64", variant: "musl", expectError: true},
+		{name: "Unsupported architecture", goos: "linux", goarch: "386", variant: VariantAuto, expectError: true},
+		{name: "Windows", goos: "windows", goarch: "amd64", variant: VariantAuto, expectedOS: "windows", expectedArch: "amd64"},
+		{name: "Windows on Arm", goos: "windows", goarch: "arm64", variant: VariantAuto, expectError: true},
+		{name: "Unsupported OS", goos: "freebsd", goarch: "amd64", variant: VariantAuto, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spinOS, spinArch, variant, err := SpinPlatform(tt.goos, tt.goarch, tt.variant, func() string {
+				if tt.detected == "" {
+					t.Errorf("expected the variant not to be detected")
+				}
+				return tt.detected
+			})
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error: %v, got: %v", tt.expectError, err)
+			}
+
+			if spinOS != tt.expectedOS || spinArch != tt.expectedArch || variant != tt.expectedVariant {
+				t.Errorf("expected %s/%s (%q), got: %s/%s (%q)", tt.expectedOS, tt.expectedArch, tt.expectedVariant, spinOS, spinArch, variant)
+			}
+		})
+	}
+}
+
+func TestDetectLinuxVariant(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		expected string
+	}{
+		{name: "Debian", files: []string{"lib64/ld-linux-x86-64.so.2", "lib/x86_64-linux-gnu/libc.so.6"}, expected: VariantGlibc},
+		{name: "Multiarch loader", files: []string{"lib/aarch64-linux-gnu/ld-linux-aarch64.so.1"}, expected: VariantGlibc},
+		{name: "Alpine", files: []string{"lib/ld-musl-x86_64.so.1"}, expected: VariantStatic},
+		{name: "Alpine with gcompat", files: []string{"lib/ld-musl-aarch64.so.1", "lib/ld-linux-aarch64.so.1"}, expected: VariantStatic},
+		{name: "No libc", expected: VariantStatic},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+
+			for _, file := range tt.files {
+				filePath := filepath.Join(root, filepath.FromSlash(file))
+				if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+					t.Fatalf("failed to create directory: %v", err)
+				}
+				if err := os.WriteFile(filePath, nil, 0755); err != nil {
+					t.Fatalf("failed to create file: %v", err)
+				}
+			}
+
+			if variant := DetectLinuxVariant(root); variant != tt.expected {
+				t.Errorf("expected variant: %s, got: %s", tt.expected, variant)
+			}
+		})
+	}
+}
+
+func TestSamePathName(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		goos     string
+		expected bool
+	}{
+		{a: "/home/user/.local/share/spin-verman/versions/current_version", b: "/home/user/.local/share/spin-verman/versions/current_version/", goos: "linux", expected: true},
+		{a: "/home/user/Versions", b: "/home/user/versions", goos: "linux", expected: false},
+		{a: `C:\Users\User\AppData\Local\spin-verman\versions\current_version`, b: `c:\users\user\appdata\local\SPIN-VERMAN\versions\current_version\`, goos: "windows", expected: true},
+		{a: `C:\Users\User\versions\current_version`, b: "C:/Users/User/versions/current_version", goos: "windows", expected: true},
+		{a: `C:\Users\User\versions`, b: `C:\Users\Other\versions`, goos: "windows", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos+" "+tt.b, func(t *testing.T) {
+			if SamePathName(tt.a, tt.b, tt.goos) != tt.expected {
+				t.Errorf("expected %q and %q to be the same on %s: %v", tt.a, tt.b, tt.goos, tt.expected)
+			}
+		})
+	}
+
+	if BinaryName("windows") != "spin.exe" || BinaryName("linux") != "spin" {
+		t.Errorf("expected spin.exe on Windows and spin elsewhere")
+	}
+}
@@ -0,0 +1,122 @@
+package verman
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// OfflineEnvVar makes offline mode the default when set to a true value
+	OfflineEnvVar = "SPIN_VERMAN_OFFLINE"
+
+	// ReleaseIndexTTLEnvVar overrides how long the cached release index is used before it is revalidated (e.g. "30m")
+	ReleaseIndexTTLEnvVar = "SPIN_VERMAN_RELEASE_INDEX_TTL"
+
+	// DefaultReleaseIndexTTL is how long the cached release index is used before it is revalidated by default
+	DefaultReleaseIndexTTL = time.Hour
+
+	// ReleaseIndexFileName is the file in the cache directory that the release index is stored in
+	ReleaseIndexFileName = "releases.json"
+)
+
+// ReleaseIndex is a cached copy of the list of published Spin releases
+type ReleaseIndex struct {
+	Source    string    `json:"source"`         // The release source the index was fetched from
+	FetchedAt time.Time `json:"fetched_at"`     // When the index was last fetched or revalidated
+	ETag      string    `json:"etag,omitempty"` // Used to revalidate the index
+	Releases  []Release `json:"releases"`
+}
+
+// OfflineByDefault reports whether offline mode has been enabled through the environment
+func OfflineByDefault() bool {
+	return envBool(OfflineEnvVar)
+}
+
+// ReleaseIndexTTLFromEnv returns the release index TTL configured through the environment, or DefaultReleaseIndexTTL
+func ReleaseIndexTTLFromEnv() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv(ReleaseIndexTTLEnvVar))
+	if err != nil || ttl < 0 {
+		return DefaultReleaseIndexTTL
+	}
+
+	return ttl
+}
+
+// Fresh indicates whether the index was fetched less than ttl ago
+func (i *ReleaseIndex) Fresh(ttl time.Duration, now time.Time) bool {
+	return now.Sub(i.FetchedAt) < ttl
+}
+
+// LatestStable returns the tag of the highest stable release in the index
+func (i *ReleaseIndex) LatestStable() (string, error) {
+	var stable []string
+	for _, release := range i.Releases {
+		if !release.Draft && !release.Prerelease {
+			stable = append(stable, release.TagName)
+		}
+	}
+
+	latest, err := ResolveVersion("stable", stable)
+	if err != nil {
+		return "", fmt.Errorf("no stable release of Spin was found in the release index")
+	}
+
+	return latest, nil
+}
+
+// ReadReleaseIndex reads the release index stored at indexPath. It returns nil if there is no index.
+func ReadReleaseIndex(indexPath string) (*ReleaseIndex, error) {
+	content, err := os.ReadFile(indexPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var index ReleaseIndex
+	if err := json.Unmarshal(content, &index); err != nil {
+		return nil, fmt.Errorf("invalid release index %s: %v", indexPath, err)
+	}
+
+	return &index, nil
+}
+
+// WriteReleaseIndex stores the release index at indexPath. The index is written to a temporary file that is renamed
+// into place, so concurrent readers never see a partially written index.
+func WriteReleaseIndex(indexPath string, index *ReleaseIndex) error {
+	content, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(indexPath), 0755); err != nil {
+		return err
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(indexPath), "."+filepath.Base(indexPath)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	// CreateTemp creates the file with 0600 permissions
+	if err := tempFile.Chmod(0644); err != nil {
+		tempFile.Close()
+		return err
+	}
+
+	if _, err := tempFile.Write(content); err != nil {
+		tempFile.Close()
+		return err
+	}
+
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), indexPath)
+}
@@ -0,0 +1,54 @@
+package verman
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReleaseIndex(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), "cache", ReleaseIndexFileName)
+
+	index, err := ReadReleaseIndex(indexPath)
+	if err != nil || index != nil {
+		t.Fatalf("expected no index before one is written, got: %v (error: %v)", index, err)
+	}
+
+	fetchedAt := time.Date(2024, 8, 1, 12, 0, 0, 0, time.UTC)
+	written := &ReleaseIndex{
+		FetchedAt: fetchedAt,
+		ETag:      `W/"abc"`,
+		Releases: []Release{
+			{TagName: "canary", Prerelease: true},
+			{TagName: "v3.0.0-rc.1", Prerelease: true},
+			{TagName: "v2.10.0"},
+			{TagName: "v2.9.0"},
+			{TagName: "v4.0.0", Draft: true},
+		},
+	}
+
+	if err := WriteReleaseIndex(indexPath, written); err != nil {
+		t.Fatalf("failed to write index: %v", err)
+	}
+
+	index, err = ReadReleaseIndex(indexPath)
+	if err != nil || index == nil {
+		t.Fatalf("failed to read index: %v", err)
+	}
+
+	if index.ETag != written.ETag || !index.FetchedAt.Equal(fetchedAt) || len(index.Releases) != len(written.Releases) {
+		t.Errorf("expected index: %+v, got: %+v", written, index)
+	}
+
+	if latest, err := index.LatestStable(); err != nil || latest != "v2.10.0" {
+		t.Errorf("expected latest stable release: v2.10.0, got: %v (error: %v)", latest, err)
+	}
+
+	if !index.Fresh(time.Hour, fetchedAt.Add(59*time.Minute)) {
+		t.Errorf("expected index to be fresh within its TTL")
+	}
+
+	if index.Fresh(time.Hour, fetchedAt.Add(61*time.Minute)) {
+		t.Errorf("expected index to be stale after its TTL")
+	}
+}
@@ -0,0 +1,133 @@
+package verman
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+)
+
+// Release is a published release of Spin, as returned by the GitHub releases API
+type Release struct {
+	TagName     string         `json:"tag_name"`
+	Name        string         `json:"name,omitempty"`
+	Prerelease  bool           `json:"prerelease"`
+	Draft       bool           `json:"draft,omitempty"`
+	PublishedAt time.Time      `json:"published_at"`
+	Assets      []ReleaseAsset `json:"assets,omitempty"`
+}
+
+// ReleaseAsset is a file attached to a release
+type ReleaseAsset struct {
+	Name        string `json:"name"`
+	Size        int64  `json:"size"`
+	DownloadURL string `json:"browser_download_url"`
+}
+
+// HasAsset indicates whether the release has an asset with the given file name
+func (r *Release) HasAsset(name string) bool {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ReleaseAssetName returns the file name of the archive containing the Spin binary for a version, OS and architecture,
+// e.g. "spin-v2.7.0-linux-amd64.tar.gz". Windows binaries are released in zip archives.
+func ReleaseAssetName(version, spinOS, spinArch string) string {
+	if spinOS == "windows" {
+		return fmt.Sprintf("spin-%s-%s-%s.zip", version, spinOS, spinArch)
+	}
+
+	return fmt.Sprintf("spin-%s-%s-%s.tar.gz", version, spinOS, spinArch)
+}
+
+// ReleaseFilter selects releases. The zero value selects every published release.
+type ReleaseFilter struct {
+	Prerelease bool      // Only pre-releases (including canary)
+	Stable     bool      // Only stable releases
+	Constraint string    // Only releases matching a version constraint such as "^2.5"
+	Since      time.Time // Only releases published at or after this time
+}
+
+// FilterReleases returns the releases selected by filter, in their original order. Drafts are never selected.
+func FilterReleases(releases []Release, filter ReleaseFilter) ([]Release, error) {
+	var c *constraint
+	if filter.Constraint != "" {
+		var err error
+		if c, err = parseConstraint(filter.Constraint); err != nil {
+			return nil, err
+		}
+
+		// Asking for pre-releases means they should match the constraint without having to name one
+		c.prerelease = c.prerelease || filter.Prerelease
+	}
+
+	var selected []Release
+	for _, release := range releases {
+		prerelease := release.Prerelease || semver.Prerelease(canonicalVersion(release.TagName)) != ""
+
+		switch {
+		case release.Draft:
+			continue
+		case filter.Prerelease && !prerelease, filter.Stable && prerelease:
+			continue
+		case !filter.Since.IsZero() && release.PublishedAt.Before(filter.Since):
+			continue
+		case c != nil && (!semver.IsValid(canonicalVersion(release.TagName)) || !c.matches(canonicalVersion(release.TagName))):
+			continue
+		}
+
+		selected = append(selected, release)
+	}
+
+	return selected, nil
+}
+
+// ParseSince parses the value of a "--since" flag relative to now. It accepts a date ("2024-06-01"), an RFC 3339
+// timestamp, or an age in days, weeks or any unit understood by time.ParseDuration ("30d", "2w", "36h").
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, err := strconv.Atoi(strings.TrimSuffix(value, suffix)); err == nil && strings.HasSuffix(value, suffix) && n >= 0 {
+			return now.Add(-time.Duration(n) * unit), nil
+		}
+	}
+
+	if age, err := time.ParseDuration(value); err == nil && age >= 0 {
+		return now.Add(-age), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q: expected a date (2024-06-01), an RFC 3339 timestamp or an age (30d, 2w, 36h)", value)
+}
+
+// NextPageURL returns the URL of the next page from the Link header of a paginated GitHub API response, or an empty
+// string if this is the last page
+func NextPageURL(linkHeader string) string {
+	for _, link := range strings.Split(linkHeader, ",") {
+		target, params, found := strings.Cut(strings.TrimSpace(link), ";")
+		if !found {
+			continue
+		}
+
+		for _, param := range strings.Split(params, ";") {
+			if strings.ReplaceAll(strings.TrimSpace(param), " ", "") == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(target), "<>")
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,163 @@
+package verman
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilterReleases(t *testing.T) {
+	date := func(day int) time.Time {
+		return time.Date(2024, 6, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	releases := []Release{
+		{TagName: "canary", Prerelease: true, PublishedAt: date(20)},
+		{TagName: "v2.7.0", PublishedAt: date(15)},
+		{TagName: "v2.7.0-rc.1", PublishedAt: date(10)},
+		{TagName: "v2.6.0", PublishedAt: date(5)},
+		{TagName: "v3.0.0-draft", Draft: true, PublishedAt: date(25)},
+		{TagName: "v2.5.1", PublishedAt: date(1)},
+	}
+
+	tests := []struct {
+		name        string
+		filter      ReleaseFilter
+		expected    []string
+		expectError bool
+	}{
+		{
+			name:     "No filter",
+			expected: []string{"canary", "v2.7.0", "v2.7.0-rc.1", "v2.6.0", "v2.5.1"},
+		},
+		{
+			name:     "Stable releases",
+			filter:   ReleaseFilter{Stable: true},
+			expected: []string{"v2.7.0", "v2.6.0", "v2.5.1"},
+		},
+		{
+			name:     "Pre-releases",
+			filter:   ReleaseFilter{Prerelease: true},
+			expected: []string{"canary", "v2.7.0-rc.1"},
+		},
+		{
+			name:     "Constraint",
+			filter:   ReleaseFilter{Constraint: ">=2.6"},
+			expected: []string{"v2.7.0", "v2.6.0"},
+		},
+		{
+			name:     "Constraint with pre-releases",
+			filter:   ReleaseFilter{Constraint: "^2.7.0-0", Prerelease: true},
+			expected: []string{"v2.7.0-rc.1"},
+		},
+		{
+			name:     "Published since",
+			filter:   ReleaseFilter{Since: date(10), Stable: true},
+			expected: []string{"v2.7.0"},
+		},
+		{
+			name:        "Invalid constraint",
+			filter:      ReleaseFilter{Constraint: ">=two"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := FilterReleases(releases, tt.filter)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error: %v, got: %v", tt.expectError, err)
+			}
+
+			var tags []string
+			for _, release := range selected {
+				tags = append(tags, release.TagName)
+			}
+
+			if !equalStringSlices(tags, tt.expected) {
+				t.Errorf("expected releases: %v, got: %v", tt.expected, tags)
+			}
+		})
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value       string
+		expected    time.Time
+		expectError bool
+	}{
+		{value: "2024-06-01", expected: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "2024-06-01T08:30:00Z", expected: time.Date(2024, 6, 1, 8, 30, 0, 0, time.UTC)},
+		{value: "30d", expected: now.Add(-30 * 24 * time.Hour)},
+		{value: "2w", expected: now.Add(-14 * 24 * time.Hour)},
+		{value: "36h", expected: now.Add(-36 * time.Hour)},
+		{value: "last week", expectError: true},
+		{value: "-3d", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			since, err := ParseSince(tt.value, now)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error: %v, got: %v", tt.expectError, err)
+			}
+			if !since.Equal(tt.expected) {
+				t.Errorf("expected: %v, got: %v", tt.expected, since)
+			}
+		})
+	}
+}
+
+func TestNextPageURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{
+			name:     "Next and last pages",
+			header:   `<https://api.github.com/repositories/1/releases?per_page=100&page=2>; rel="next", <https://api.github.com/repositories/1/releases?per_page=100&page=3>; rel="last"`,
+			expected: "https://api.github.com/repositories/1/releases?per_page=100&page=2",
+		},
+		{
+			name:     "Last page",
+			header:   `<https://api.github.com/repositories/1/releases?page=1>; rel="prev", <https://api.github.com/repositories/1/releases?page=1>; rel="first"`,
+			expected: "",
+		},
+		{
+			name:     "No header",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if next := NextPageURL(tt.header); next != tt.expected {
+				t.Errorf("expected: %q, got: %q", tt.expected, next)
+			}
+		})
+	}
+}
+
+func TestReleaseAssetName(t *testing.T) {
+	tests := []struct {
+		spinOS   string
+		spinArch string
+		expected string
+	}{
+		{spinOS: "linux", spinArch: "amd64", expected: "spin-v2.7.0-linux-amd64.tar.gz"},
+		{spinOS: "static-linux", spinArch: "aarch64", expected: "spin-v2.7.0-static-linux-aarch64.tar.gz"},
+		{spinOS: "macos", spinArch: "aarch64", expected: "spin-v2.7.0-macos-aarch64.tar.gz"},
+		{spinOS: "windows", spinArch: "amd64", expected: "spin-v2.7.0-windows-amd64.zip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if assetName := ReleaseAssetName("v2.7.0", tt.spinOS, tt.spinArch); assetName != tt.expected {
+				t.Errorf("expected asset: %s, got: %s", tt.expected, assetName)
+			}
+		})
+	}
+}
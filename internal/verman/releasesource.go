@@ -0,0 +1,376 @@
+package verman
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+const (
+	// MirrorEnvVar selects a release source: either the base URL of an HTTP mirror, or a local directory
+	MirrorEnvVar = "SPIN_VERMAN_MIRROR"
+
+	// DefaultGitHubRepository is the repository Spin is released from
+	DefaultGitHubRepository = "fermyon/spin"
+
+	// MirrorIndexFileName is the name of the release index in a mirror or local directory that follows the default layout
+	MirrorIndexFileName = "releases.json"
+
+	githubAPIURL = "https://api.github.com"
+	githubURL    = "https://github.com"
+
+	// githubReleasesPerPage is the largest page size the GitHub releases API allows
+	githubReleasesPerPage = 100
+)
+
+// The types of release sources that can be configured
+const (
+	SourceGitHub = "github"
+	SourceMirror = "mirror"
+	SourceLocal  = "local"
+)
+
+// ReleaseSource is where Spin releases are listed and downloaded from
+type ReleaseSource interface {
+	// String identifies the source, e.g. "github:fermyon/spin"
+	String() string
+
+	// ListReleases returns every published release. If etag is not empty and the releases haven't changed since it was
+	// returned, notModified is set and no releases are returned.
+	ListReleases(etag string) (releases []Release, newETag string, notModified bool, err error)
+
+	// AssetURL returns the URL a release asset is downloaded from. Local files have "file://" URLs.
+	AssetURL(version, assetName string) string
+}
+
+// ReleaseSourceConfig configures the release source
+type ReleaseSourceConfig struct {
+	Type string `json:"type,omitempty"` // "github" (the default), "mirror" or "local"
+
+	// Repository is the GitHub repository to use, defaulting to "fermyon/spin"
+	Repository string `json:"repository,omitempty"`
+
+	// URL is the base URL of a mirror with the same layout as GitHub release downloads ("<url>/<version>/<asset>")
+	// and a "releases.json" index. IndexURL and URLTemplate override the URLs derived from it.
+	URL         string `json:"url,omitempty"`
+	IndexURL    string `json:"index_url,omitempty"`
+	URLTemplate string `json:"url_template,omitempty"` // e.g. "https://mirror.internal/spin/{version}/{asset}"
+
+	// Path is the directory of a local source, containing a directory of assets per version
+	Path string `json:"path,omitempty"`
+}
+
+// NewReleaseSource creates the release source described by config. A non-empty mirror (typically the value of
+// $SPIN_VERMAN_MIRROR) takes precedence over config: an "http://" or "https://" URL selects an HTTP mirror, and anything
+// else a local directory.
+func NewReleaseSource(config ReleaseSourceConfig, mirror string, client *http.Client) (ReleaseSource, error) {
+	if mirror != "" {
+		if strings.HasPrefix(mirror, "http://") || strings.HasPrefix(mirror, "https://") {
+			config = ReleaseSourceConfig{Type: SourceMirror, URL: mirror}
+		} else {
+			config = ReleaseSourceConfig{Type: SourceLocal, Path: strings.TrimPrefix(mirror, "file://")}
+		}
+	}
+
+	switch config.Type {
+	case "", SourceGitHub:
+		repository := config.Repository
+		if repository == "" {
+			repository = DefaultGitHubRepository
+		}
+
+		return &GitHubSource{Client: client, Repository: repository, APIURL: githubAPIURL, DownloadURL: githubURL}, nil
+	case SourceMirror:
+		source := &MirrorSource{Client: client, IndexURL: config.IndexURL, URLTemplate: config.URLTemplate}
+
+		if base := strings.TrimSuffix(config.URL, "/"); base != "" {
+			if source.IndexURL == "" {
+				source.IndexURL = base + "/" + MirrorIndexFileName
+			}
+			if source.URLTemplate == "" {
+				source.URLTemplate = base + "/{version}/{asset}"
+			}
+		}
+
+		if source.IndexURL == "" || source.URLTemplate == "" {
+			return nil, fmt.Errorf("a mirror release source requires a url, or an index_url and a url_template")
+		}
+
+		return source, nil
+	case SourceLocal:
+		if config.Path == "" {
+			return nil, fmt.Errorf("a local release source requires a path")
+		}
+
+		dir, err := filepath.Abs(config.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		return &LocalSource{Dir: dir}, nil
+	default:
+		return nil, fmt.Errorf("unknown release source type %q (expected %s, %s or %s)", config.Type, SourceGitHub, SourceMirror, SourceLocal)
+	}
+}
+
+// GitHubSource lists and downloads releases from a GitHub repository. Requests are authenticated by the client (see
+// NewHTTPClient).
+type GitHubSource struct {
+	Client      *http.Client
+	Repository  string
+	APIURL      string // e.g. "https://api.github.com"
+	DownloadURL string // e.g. "https://github.com"
+}
+
+func (s *GitHubSource) String() string {
+	return SourceGitHub + ":" + s.Repository
+}
+
+func (s *GitHubSource) AssetURL(version, assetName string) string {
+	return fmt.Sprintf("%s/%s/releases/download/%s/%s", s.DownloadURL, s.Repository, version, assetName)
+}
+
+// ListReleases follows the pagination of the GitHub releases API. New releases always appear on the first page, so
+// only the first page is revalidated with etag.
+func (s *GitHubSource) ListReleases(etag string) ([]Release, string, bool, error) {
+	var releases []Release
+	var newETag string
+
+	pageURL := fmt.Sprintf("%s/repos/%s/releases?per_page=%d", s.APIURL, s.Repository, githubReleasesPerPage)
+
+	for first := true; pageURL != ""; first = false {
+		requestETag := ""
+		if first {
+			requestETag = etag
+		}
+
+		resp, err := s.get(pageURL, requestETag)
+		if err != nil {
+			return nil, "", false, err
+		}
+
+		if resp.StatusCode == http.StatusNotModified {
+			resp.Body.Close()
+			return nil, etag, true, nil
+		}
+
+		var page []Release
+		err = decodeJSON(resp, &page)
+		if err != nil {
+			return nil, "", false, err
+		}
+
+		if first {
+			newETag = resp.Header.Get("ETag")
+		}
+
+		releases = append(releases, page...)
+		pageURL = NextPageURL(resp.Header.Get("Link"))
+	}
+
+	return releases, newETag, false, nil
+}
+
+func (s *GitHubSource) get(url, etag string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create request: %v", err)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load available Spin releases: %v", err)
+	}
+
+	// the GitHub token is a bad credential
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		return nil, fmt.Errorf("Unauthorized: Bad credentials. Please check your GitHub token (%s, %s or \"gh auth token\").", GitHubTokenEnvVar, GitHubTokenFallbackEnvVar)
+	}
+
+	return resp, nil
+}
+
+// MirrorSource lists releases from a JSON index and downloads them from a URL template, for HTTP servers that mirror
+// Spin releases. The index has the same format as the GitHub releases API, either as an array of releases or as an
+// object with a "releases" array.
+type MirrorSource struct {
+	Client      *http.Client
+	IndexURL    string
+	URLTemplate string // "{version}" and "{asset}" are replaced by the release version and asset file name
+}
+
+func (s *MirrorSource) String() string {
+	return SourceMirror + ":" + s.IndexURL
+}
+
+func (s *MirrorSource) AssetURL(version, assetName string) string {
+	return strings.NewReplacer("{version}", url.PathEscape(version), "{asset}", url.PathEscape(assetName)).Replace(s.URLTemplate)
+}
+
+func (s *MirrorSource) ListReleases(etag string) ([]Release, string, bool, error) {
+	req, err := http.NewRequest(http.MethodGet, s.IndexURL, nil)
+	if err != nil {
+		return nil, "", false, err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("unable to load the release index from %s: %v", s.IndexURL, err)
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return nil, etag, true, nil
+	}
+
+	releases, err := decodeReleaseIndex(resp)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("unable to load the release index from %s: %v", s.IndexURL, err)
+	}
+
+	return releases, resp.Header.Get("ETag"), false, nil
+}
+
+// LocalSource lists and installs releases from a local directory containing a directory of assets per version (the
+// same layout as GitHub release downloads). If the directory has a "releases.json" index it is used to list the
+// releases, otherwise they are discovered from the version directories.
+type LocalSource struct {
+	Dir string
+}
+
+func (s *LocalSource) String() string {
+	return SourceLocal + ":" + s.Dir
+}
+
+func (s *LocalSource) AssetURL(version, assetName string) string {
+	assetPath := filepath.ToSlash(filepath.Join(s.Dir, version, assetName))
+	if !strings.HasPrefix(assetPath, "/") {
+		assetPath = "/" + assetPath
+	}
+
+	return (&url.URL{Scheme: "file", Path: assetPath}).String()
+}
+
+func (s *LocalSource) ListReleases(etag string) ([]Release, string, bool, error) {
+	index, err := os.Open(filepath.Join(s.Dir, MirrorIndexFileName))
+	if err == nil {
+		defer index.Close()
+
+		releases, err := parseReleaseIndex(index)
+		if err != nil {
+			return nil, "", false, fmt.Errorf("invalid release index in %s: %v", s.Dir, err)
+		}
+
+		return releases, "", false, nil
+	}
+
+	if !os.IsNotExist(err) {
+		return nil, "", false, err
+	}
+
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	var releases []Release
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		release := Release{TagName: entry.Name(), Prerelease: entry.Name() == "canary" || semver.Prerelease(canonicalVersion(entry.Name())) != ""}
+
+		if info, err := entry.Info(); err == nil {
+			release.PublishedAt = info.ModTime().UTC()
+		}
+
+		assets, err := os.ReadDir(filepath.Join(s.Dir, entry.Name()))
+		if err != nil {
+			return nil, "", false, err
+		}
+
+		for _, asset := range assets {
+			if info, err := asset.Info(); err == nil && !asset.IsDir() {
+				release.Assets = append(release.Assets, ReleaseAsset{Name: asset.Name(), Size: info.Size(), DownloadURL: s.AssetURL(entry.Name(), asset.Name())})
+			}
+		}
+
+		releases = append(releases, release)
+	}
+
+	// Match the order of the GitHub releases API, which lists the newest releases first
+	sort.SliceStable(releases, func(i, j int) bool {
+		return semver.Compare(canonicalVersion(releases[i].TagName), canonicalVersion(releases[j].TagName)) > 0
+	})
+
+	return releases, "", false, nil
+}
+
+// decodeJSON decodes a successful JSON response into v, closing the response body
+func decodeJSON(resp *http.Response, v any) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return StatusError(resp, "Failed to load available Spin releases")
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("Failed to read response body: %v", err)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("Failed to unmarshal JSON: %v", err)
+	}
+
+	return nil
+}
+
+func decodeReleaseIndex(resp *http.Response) ([]Release, error) {
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, StatusError(resp, "unexpected response")
+	}
+
+	return parseReleaseIndex(resp.Body)
+}
+
+// parseReleaseIndex parses a mirror's release index, which is either an array of releases or an object with a
+// "releases" array
+func parseReleaseIndex(r io.Reader) ([]Release, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []Release
+	if err := json.Unmarshal(content, &releases); err == nil {
+		return releases, nil
+	}
+
+	var index struct {
+		Releases []Release `json:"releases"`
+	}
+	if err := json.Unmarshal(content, &index); err != nil {
+		return nil, err
+	}
+
+	return index.Releases, nil
+}
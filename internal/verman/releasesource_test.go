@@ -0,0 +1,187 @@
+package verman
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewReleaseSource(t *testing.T) {
+	localDir := t.TempDir()
+
+	tests := []struct {
+		name        string
+		config      ReleaseSourceConfig
+		mirror      string
+		expected    string
+		assetURL    string
+		expectError bool
+	}{
+		{
+			name:     "GitHub by default",
+			expected: "github:fermyon/spin",
+			assetURL: "https://github.com/fermyon/spin/releases/download/v2.7.0/spin.tar.gz",
+		},
+		{
+			name:     "GitHub fork",
+			config:   ReleaseSourceConfig{Type: SourceGitHub, Repository: "example/spin"},
+			expected: "github:example/spin",
+			assetURL: "https://github.com/example/spin/releases/download/v2.7.0/spin.tar.gz",
+		},
+		{
+			name:     "Mirror from a base URL",
+			config:   ReleaseSourceConfig{Type: SourceMirror, URL: "https://mirror.internal/spin/"},
+			expected: "mirror:https://mirror.internal/spin/releases.json",
+			assetURL: "https://mirror.internal/spin/v2.7.0/spin.tar.gz",
+		},
+		{
+			name:     "Mirror with a URL template",
+			config:   ReleaseSourceConfig{Type: SourceMirror, IndexURL: "https://artifacts.internal/spin/index.json", URLTemplate: "https://artifacts.internal/spin/{asset}?version={version}"},
+			expected: "mirror:https://artifacts.internal/spin/index.json",
+			assetURL: "https://artifacts.internal/spin/spin.tar.gz?version=v2.7.0",
+		},
+		{
+			name:     "Mirror from the environment takes precedence",
+			config:   ReleaseSourceConfig{Type: SourceLocal, Path: localDir},
+			mirror:   "http://mirror.internal",
+			expected: "mirror:http://mirror.internal/releases.json",
+			assetURL: "http://mirror.internal/v2.7.0/spin.tar.gz",
+		},
+		{
+			name:     "Local directory from the environment",
+			mirror:   localDir,
+			expected: "local:" + localDir,
+			assetURL: "file://" + filepath.ToSlash(filepath.Join(localDir, "v2.7.0", "spin.tar.gz")),
+		},
+		{
+			name:        "Mirror without URLs",
+			config:      ReleaseSourceConfig{Type: SourceMirror, IndexURL: "https://mirror.internal/index.json"},
+			expectError: true,
+		},
+		{
+			name:        "Unknown type",
+			config:      ReleaseSourceConfig{Type: "s3"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := NewReleaseSource(tt.config, tt.mirror, http.DefaultClient)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error: %v, got: %v", tt.expectError, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if source.String() != tt.expected {
+				t.Errorf("expected source: %s, got: %s", tt.expected, source)
+			}
+			if assetURL := source.AssetURL("v2.7.0", "spin.tar.gz"); assetURL != tt.assetURL {
+				t.Errorf("expected asset URL: %s, got: %s", tt.assetURL, assetURL)
+			}
+		})
+	}
+}
+
+func TestGitHubSourceListReleases(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"page-1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `[{"tag_name": "v2.6.0"}]`)
+			return
+		}
+
+		w.Header().Set("ETag", `"page-1"`)
+		w.Header().Set("Link", fmt.Sprintf(`<%s/repos/fermyon/spin/releases?page=2>; rel="next"`, server.URL))
+		fmt.Fprint(w, `[{"tag_name": "canary", "prerelease": true}, {"tag_name": "v2.7.0"}]`)
+	}))
+	defer server.Close()
+
+	source := &GitHubSource{Client: server.Client(), Repository: "fermyon/spin", APIURL: server.URL}
+
+	releases, etag, notModified, err := source.ListReleases("")
+	if err != nil || notModified {
+		t.Fatalf("failed to list releases: %v (not modified: %v)", err, notModified)
+	}
+
+	if len(releases) != 3 || releases[2].TagName != "v2.6.0" || etag != `"page-1"` {
+		t.Errorf("expected releases from both pages with the ETag of the first, got: %+v (ETag: %s)", releases, etag)
+	}
+
+	releases, _, notModified, err = source.ListReleases(etag)
+	if err != nil || !notModified || releases != nil {
+		t.Errorf("expected unchanged releases not to be fetched again, got: %+v (not modified: %v, error: %v)", releases, notModified, err)
+	}
+}
+
+func TestLocalSource(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, asset := range []string{"v2.7.0/spin-v2.7.0-linux-amd64.tar.gz", "v2.7.0/checksums-v2.7.0.txt", "v2.8.0-rc.1/spin-v2.8.0-rc.1-linux-amd64.tar.gz"} {
+		assetPath := filepath.Join(dir, filepath.FromSlash(asset))
+		if err := os.MkdirAll(filepath.Dir(assetPath), 0755); err != nil {
+			t.Fatalf("failed to create release directory: %v", err)
+		}
+		if err := os.WriteFile(assetPath, []byte(asset), 0644); err != nil {
+			t.Fatalf("failed to write asset: %v", err)
+		}
+	}
+
+	source := &LocalSource{Dir: dir}
+
+	releases, _, _, err := source.ListReleases("")
+	if err != nil {
+		t.Fatalf("failed to list releases: %v", err)
+	}
+
+	if len(releases) != 2 {
+		t.Fatalf("expected a release per version directory, got: %+v", releases)
+	}
+
+	for _, release := range releases {
+		if release.TagName == "v2.7.0" && (release.Prerelease || len(release.Assets) != 2 || !release.HasAsset("spin-v2.7.0-linux-amd64.tar.gz")) {
+			t.Errorf("expected stable release with two assets, got: %+v", release)
+		}
+		if release.TagName == "v2.8.0-rc.1" && !release.Prerelease {
+			t.Errorf("expected pre-release, got: %+v", release)
+		}
+	}
+
+	// Assets are downloaded with the same client as remote ones
+	client, err := NewHTTPClient(HTTPClientOptions{})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(source.AssetURL("v2.7.0", "checksums-v2.7.0.txt"))
+	if err != nil {
+		t.Fatalf("failed to download local asset: %v", err)
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil || resp.StatusCode != http.StatusOK || string(content) != "v2.7.0/checksums-v2.7.0.txt" {
+		t.Errorf("expected local asset content, got: %q (status %d, error: %v)", content, resp.StatusCode, err)
+	}
+
+	// An index in the directory takes precedence over discovering the releases
+	if err := os.WriteFile(filepath.Join(dir, MirrorIndexFileName), []byte(`{"releases": [{"tag_name": "v2.7.0"}]}`), 0644); err != nil {
+		t.Fatalf("failed to write index: %v", err)
+	}
+
+	releases, _, _, err = source.ListReleases("")
+	if err != nil || len(releases) != 1 {
+		t.Errorf("expected releases from the index, got: %+v (error: %v)", releases, err)
+	}
+}
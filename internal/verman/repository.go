@@ -0,0 +1,314 @@
+package verman
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+)
+
+const (
+	// MetadataFileName is the file in each installed version's directory that describes the install
+	MetadataFileName = "verman.json"
+
+	// CurrentVersionDirName is the directory in the versions directory that is added to $PATH
+	CurrentVersionDirName = "current_version"
+
+	// InstallDirPrefix prefixes the temporary directories that versions are extracted into before being renamed into place
+	InstallDirPrefix = ".install-"
+)
+
+// The kinds of installed versions
+const (
+	KindRelease  = "release"
+	KindCanary   = "canary"
+	KindBuild    = "build"
+	KindArtifact = "artifact" // A CI build of a pull request or commit
+	KindAlias    = "alias"
+)
+
+// Metadata describes an installed version of Spin
+type Metadata struct {
+	Version       string    `json:"version"`
+	Kind          string    `json:"kind"`
+	Source        string    `json:"source,omitempty"`         // URL the archive was downloaded from, the repository a build was built from, or the path an alias points to
+	ArchiveSHA256 string    `json:"archive_sha256,omitempty"` // Digest of the downloaded archive
+	SHA256        string    `json:"sha256,omitempty"`         // Digest of the installed binary
+	Size          int64     `json:"size,omitempty"`           // Size of the installed binary in bytes
+	Variant       string    `json:"variant,omitempty"`        // Build variant on Linux: "glibc" or "static"
+	Platform      string    `json:"platform,omitempty"`       // OS and architecture when installed for another platform, e.g. "linux/arm64"
+	Commit        string    `json:"commit,omitempty"`         // Commit SHA a build (from source or CI) or canary was built from
+	Features      []string  `json:"features,omitempty"`       // Cargo features a build was built with
+	BuildDate     string    `json:"build_date,omitempty"`     // Date a canary was built, e.g. "2024-09-30"
+	InstalledAt   time.Time `json:"installed_at"`
+
+	// Inferred is set when the version was installed without metadata (e.g. by an older release of verman) and the
+	// metadata was reconstructed from the files on disk
+	Inferred bool `json:"-"`
+}
+
+// InstalledVersion is a version of Spin (or an alias) in the versions directory
+type InstalledVersion struct {
+	Name     string // Name of the version's directory, e.g. "v2.7.0", "canary" or an alias
+	Dir      string
+	Metadata *Metadata
+
+	binaryName string
+}
+
+// BinaryPath is the path of the version's Spin binary (or the alias symlink)
+func (v *InstalledVersion) BinaryPath() string {
+	if v.binaryName == "" {
+		return filepath.Join(v.Dir, BinaryName(runtime.GOOS))
+	}
+
+	return filepath.Join(v.Dir, v.binaryName)
+}
+
+// Repository provides access to the versions of Spin installed in a versions directory
+type Repository struct {
+	Dir        string
+	BinaryName string // File name of the Spin binary in each version's directory, which depends on the OS it is for
+}
+
+// NewRepository returns a repository for the given versions directory
+func NewRepository(dir string) *Repository {
+	return NewRepositoryForOS(dir, runtime.GOOS)
+}
+
+// NewRepositoryForOS returns a repository for a versions directory containing Spin binaries for the given Go OS, such
+// as a directory of binaries downloaded for another platform
+func NewRepositoryForOS(dir, goos string) *Repository {
+	return &Repository{Dir: dir, BinaryName: BinaryName(goos)}
+}
+
+// Names returns the names of every entry in the versions directory, excluding the "current_version" directory, the
+// directory of versions downloaded for other platforms and hidden temporary directories. Entries without a Spin binary (e.g. left over from an older, non-atomic install) are
+// included so they can be removed.
+func (r *Repository) Names() ([]string, error) {
+	entries, err := os.ReadDir(r.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.Name() != CurrentVersionDirName && entry.Name() != PlatformsDirName && !strings.HasPrefix(entry.Name(), ".") {
+			names = append(names, entry.Name())
+		}
+	}
+
+	return names, nil
+}
+
+// List returns every installed version that has a Spin binary, in the order given by SortInstalledVersions
+func (r *Repository) List() ([]*InstalledVersion, error) {
+	names, err := r.Names()
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []*InstalledVersion
+	for _, name := range names {
+		version, err := r.Get(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if version != nil {
+			versions = append(versions, version)
+		}
+	}
+
+	SortInstalledVersions(versions)
+
+	return versions, nil
+}
+
+// Get returns the installed version with the given name, or nil if it isn't installed
+func (r *Repository) Get(name string) (*InstalledVersion, error) {
+	installed, err := r.IsInstalled(name)
+	if err != nil || !installed {
+		return nil, err
+	}
+
+	version := &InstalledVersion{Name: name, Dir: filepath.Join(r.Dir, name), binaryName: r.BinaryName}
+
+	version.Metadata, err = ReadMetadata(version.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if version.Metadata == nil {
+		version.Metadata, err = inferMetadata(version)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// An alias's binary can be rebuilt at any time, so its size is read from the binary it points to
+	if version.Metadata.Kind == KindAlias {
+		version.Metadata.Size = 0
+		if target, err := os.Stat(version.BinaryPath()); err == nil {
+			version.Metadata.Size = target.Size()
+		}
+	}
+
+	return version, nil
+}
+
+// SortInstalledVersions orders versions for display: releases in ascending semver order, followed by canary, builds
+// (from source or CI) and then aliases in alphabetical order
+func SortInstalledVersions(versions []*InstalledVersion) {
+	group := func(v *InstalledVersion) int {
+		switch {
+		case v.Metadata.Kind == KindAlias:
+			return 4
+		case v.Metadata.Kind == KindBuild || v.Metadata.Kind == KindArtifact:
+			return 3
+		case v.Metadata.Kind == KindCanary:
+			return 2
+		case !semver.IsValid(v.Name):
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		a, b := versions[i], versions[j]
+		if group(a) != group(b) {
+			return group(a) < group(b)
+		}
+
+		if group(a) == 0 {
+			if c := semver.Compare(a.Name, b.Name); c != 0 {
+				return c < 0
+			}
+		}
+
+		return a.Name < b.Name
+	})
+}
+
+// IsInstalled indicates whether the versions directory contains a usable Spin binary (or alias symlink) for the given
+// version
+func (r *Repository) IsInstalled(name string) (bool, error) {
+	if name == "" || name == CurrentVersionDirName || strings.HasPrefix(name, ".") {
+		return false, nil
+	}
+
+	_, err := os.Lstat(filepath.Join(r.Dir, name, r.BinaryName))
+	if err == nil {
+		return true, nil
+	}
+
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	return false, err
+}
+
+// Lookup returns the name of the entry in the versions directory for the requested version, accepting versions
+// without their "v" prefix. It returns an empty string if there is no such entry.
+func (r *Repository) Lookup(requested string) (string, error) {
+	names, err := r.Names()
+	if err != nil {
+		return "", err
+	}
+
+	for _, candidate := range []string{requested, "v" + requested} {
+		for _, name := range names {
+			if name == candidate {
+				return name, nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// RemoveStaleInstallDirs cleans up the temporary directories left behind by installs that crashed more than maxAge ago
+func (r *Repository) RemoveStaleInstallDirs(maxAge time.Duration) {
+	entries, err := os.ReadDir(r.Dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), InstallDirPrefix) {
+			continue
+		}
+
+		if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) > maxAge {
+			os.RemoveAll(filepath.Join(r.Dir, entry.Name()))
+		}
+	}
+}
+
+// ReadMetadata reads the metadata of the version installed in dir. It returns nil if the version has no metadata.
+func ReadMetadata(dir string) (*Metadata, error) {
+	content, err := os.ReadFile(filepath.Join(dir, MetadataFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var metadata Metadata
+	if err := json.Unmarshal(content, &metadata); err != nil {
+		return nil, fmt.Errorf("invalid %s in %s: %v", MetadataFileName, dir, err)
+	}
+
+	return &metadata, nil
+}
+
+// WriteMetadata records the metadata of the version installed in dir
+func WriteMetadata(dir string, metadata *Metadata) error {
+	content, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, MetadataFileName), append(content, '\n'), 0644)
+}
+
+// inferMetadata reconstructs the metadata of a version installed without it from the files on disk
+func inferMetadata(version *InstalledVersion) (*Metadata, error) {
+	metadata := &Metadata{Version: version.Name, Kind: KindRelease, Inferred: true}
+
+	if version.Name == "canary" || IsCanarySnapshot(version.Name) {
+		metadata.Kind = KindCanary
+	}
+
+	info, err := os.Lstat(version.BinaryPath())
+	if err != nil {
+		return nil, err
+	}
+
+	metadata.InstalledAt = info.ModTime()
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		metadata.Kind = KindAlias
+		metadata.Source, _ = os.Readlink(version.BinaryPath())
+	} else {
+		metadata.Size = info.Size()
+	}
+
+	if digest, err := ReadDigestFile(filepath.Join(version.Dir, DigestFileName), filepath.Base(version.BinaryPath())); err == nil {
+		metadata.SHA256 = digest
+	}
+
+	return metadata, nil
+}
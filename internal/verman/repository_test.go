@@ -0,0 +1,153 @@
+package verman
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRepository(t *testing.T) {
+	dir := t.TempDir()
+	repository := NewRepository(dir)
+
+	writeBinary := func(name string) string {
+		t.Helper()
+		binaryPath := filepath.Join(dir, name, "spin")
+		if err := os.MkdirAll(filepath.Dir(binaryPath), 0755); err != nil {
+			t.Fatalf("failed to create version directory: %v", err)
+		}
+		if err := os.WriteFile(binaryPath, []byte("spin "+name), 0755); err != nil {
+			t.Fatalf("failed to write binary: %v", err)
+		}
+		return binaryPath
+	}
+
+	writeBinary("v2.7.0")
+	writeBinary("canary")
+	localBuild := writeBinary(".build")
+	if err := os.MkdirAll(filepath.Join(dir, "dev"), 0755); err != nil {
+		t.Fatalf("failed to create alias directory: %v", err)
+	}
+	if err := os.Symlink(localBuild, filepath.Join(dir, "dev", "spin")); err != nil {
+		t.Fatalf("failed to create alias: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "v2.6.0"), 0755); err != nil {
+		t.Fatalf("failed to create empty version directory: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, CurrentVersionDirName), 0755); err != nil {
+		t.Fatalf("failed to create current version directory: %v", err)
+	}
+	// Versions for other platforms are managed with --os and --arch, so "remove all" must not delete them
+	if err := os.MkdirAll(filepath.Join(dir, PlatformsDirName, "linux-arm64", "v2.7.0"), 0755); err != nil {
+		t.Fatalf("failed to create platform versions directory: %v", err)
+	}
+
+	installedAt := time.Date(2024, 8, 1, 12, 0, 0, 0, time.UTC)
+	if err := WriteMetadata(filepath.Join(dir, "v2.7.0"), &Metadata{Version: "v2.7.0", Kind: KindRelease, Source: "https://example.com/spin.tar.gz", Size: 14, InstalledAt: installedAt}); err != nil {
+		t.Fatalf("failed to write metadata: %v", err)
+	}
+
+	names, err := repository.Names()
+	if err != nil {
+		t.Fatalf("failed to list names: %v", err)
+	}
+	if len(names) != 4 {
+		t.Errorf("expected names of the versions, the alias and the empty directory, got: %v", names)
+	}
+
+	versions, err := repository.List()
+	if err != nil {
+		t.Fatalf("failed to list versions: %v", err)
+	}
+
+	expected := []struct {
+		name     string
+		kind     string
+		inferred bool
+	}{
+		{name: "v2.7.0", kind: KindRelease},
+		{name: "canary", kind: KindCanary, inferred: true},
+		{name: "dev", kind: KindAlias, inferred: true},
+	}
+
+	if len(versions) != len(expected) {
+		t.Fatalf("expected %d installed versions, got: %d", len(expected), len(versions))
+	}
+
+	for i, tt := range expected {
+		version := versions[i]
+		if version.Name != tt.name || version.Metadata.Kind != tt.kind || version.Metadata.Inferred != tt.inferred {
+			t.Errorf("expected %s (%s, inferred: %v), got: %s (%s, inferred: %v)", tt.name, tt.kind, tt.inferred, version.Name, version.Metadata.Kind, version.Metadata.Inferred)
+		}
+	}
+
+	if !versions[0].Metadata.InstalledAt.Equal(installedAt) {
+		t.Errorf("expected recorded install time: %v, got: %v", installedAt, versions[0].Metadata.InstalledAt)
+	}
+
+	if versions[2].Metadata.Source != localBuild || versions[2].Metadata.Size != int64(len("spin .build")) {
+		t.Errorf("expected alias of %s with the size of its target, got: %+v", localBuild, versions[2].Metadata)
+	}
+
+	for requested, expectedName := range map[string]string{"2.7.0": "v2.7.0", "v2.7.0": "v2.7.0", "dev": "dev", "2.8.0": ""} {
+		name, err := repository.Lookup(requested)
+		if err != nil || name != expectedName {
+			t.Errorf("expected lookup of %s to return %q, got: %q (error: %v)", requested, expectedName, name, err)
+		}
+	}
+
+	if installed, err := repository.IsInstalled("v2.6.0"); err != nil || installed {
+		t.Errorf("expected a version directory without a binary not to be installed, got: %v (error: %v)", installed, err)
+	}
+}
+
+func TestRepositoryForOS(t *testing.T) {
+	dir := t.TempDir()
+
+	binaryPath := filepath.Join(dir, "v2.7.0", "spin.exe")
+	if err := os.MkdirAll(filepath.Dir(binaryPath), 0755); err != nil {
+		t.Fatalf("failed to create version directory: %v", err)
+	}
+	if err := os.WriteFile(binaryPath, []byte("spin.exe"), 0755); err != nil {
+		t.Fatalf("failed to write binary: %v", err)
+	}
+
+	if installed, err := NewRepositoryForOS(dir, "linux").IsInstalled("v2.7.0"); err != nil || installed {
+		t.Errorf("expected a Windows binary not to be installed for Linux")
+	}
+
+	version, err := NewRepositoryForOS(dir, "windows").Get("v2.7.0")
+	if err != nil || version == nil {
+		t.Fatalf("expected a Windows binary to be installed for Windows, got: %v", err)
+	}
+
+	if version.BinaryPath() != binaryPath || version.Metadata.Size != int64(len("spin.exe")) {
+		t.Errorf("expected the Windows binary, got: %s (%d bytes)", version.BinaryPath(), version.Metadata.Size)
+	}
+}
+
+func TestSortInstalledVersions(t *testing.T) {
+	versions := []*InstalledVersion{
+		{Name: "dev", Metadata: &Metadata{Kind: KindAlias}},
+		{Name: "v2.10.0", Metadata: &Metadata{Kind: KindRelease}},
+		{Name: "canary", Metadata: &Metadata{Kind: KindCanary}},
+		{Name: "custom", Metadata: &Metadata{Kind: KindRelease}},
+		{Name: "v2.7.0", Metadata: &Metadata{Kind: KindRelease}},
+		{Name: "v2.7.0-rc.1", Metadata: &Metadata{Kind: KindRelease}},
+		{Name: "bleeding", Metadata: &Metadata{Kind: KindAlias}},
+		{Name: "build-main-1a2b3c4", Metadata: &Metadata{Kind: KindBuild}},
+	}
+
+	SortInstalledVersions(versions)
+
+	var names []string
+	for _, version := range versions {
+		names = append(names, version.Name)
+	}
+
+	expected := []string{"v2.7.0-rc.1", "v2.7.0", "v2.10.0", "custom", "canary", "build-main-1a2b3c4", "bleeding", "dev"}
+	if !equalStringSlices(names, expected) {
+		t.Errorf("expected order: %v, got: %v", expected, names)
+	}
+}
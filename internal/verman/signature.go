@@ -0,0 +1,62 @@
+package verman
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const (
+	// VerifySignatureEnvVar makes signature verification the default when set to a true value (e.g. "1" or "true")
+	VerifySignatureEnvVar = "SPIN_VERMAN_VERIFY_SIGNATURE"
+
+	// SignatureFileName and CertificateFileName are the cosign signature and certificate shipped in each Spin release archive
+	SignatureFileName   = "spin.sig"
+	CertificateFileName = "crt.pem"
+
+	spinReleaseWorkflow = "https://github.com/fermyon/spin/.github/workflows/release.yml"
+	githubOIDCIssuer    = "https://token.actions.githubusercontent.com"
+	spinRepository      = "fermyon/spin"
+)
+
+// VerifySignatureByDefault reports whether signature verification has been enabled through the environment
+func VerifySignatureByDefault() bool {
+	return envBool(VerifySignatureEnvVar)
+}
+
+// SignatureIdentity returns the certificate identity that the GitHub Actions release workflow signs the given Spin version with
+func SignatureIdentity(version string) string {
+	if version == "canary" {
+		return spinReleaseWorkflow + "@refs/heads/main"
+	}
+
+	return spinReleaseWorkflow + "@refs/tags/" + version
+}
+
+// VerifySignature uses cosign to check that binaryPath was signed by the Spin release workflow for the given version
+func VerifySignature(binaryPath, signaturePath, certificatePath, version string) error {
+	cosign, err := exec.LookPath("cosign")
+	if err != nil {
+		return fmt.Errorf("signature verification requires cosign to be installed and in $PATH (see https://docs.sigstore.dev/cosign/system_config/installation/): %v", err)
+	}
+
+	verifyCmd := exec.Command(cosign, "verify-blob",
+		"--signature", signaturePath,
+		"--certificate", certificatePath,
+		"--certificate-identity", SignatureIdentity(version),
+		"--certificate-oidc-issuer", githubOIDCIssuer,
+		"--certificate-github-workflow-repository", spinRepository,
+		binaryPath,
+	)
+
+	var output bytes.Buffer
+	verifyCmd.Stdout = &output
+	verifyCmd.Stderr = &output
+
+	if err := verifyCmd.Run(); err != nil {
+		return fmt.Errorf("signature verification failed for Spin version %s: %v\n%s", version, err, strings.TrimSpace(output.String()))
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package verman
+
+import "testing"
+
+func TestSignatureIdentity(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{
+			version:  "v2.7.0",
+			expected: "https://github.com/fermyon/spin/.github/workflows/release.yml@refs/tags/v2.7.0",
+		},
+		{
+			version:  "canary",
+			expected: "https://github.com/fermyon/spin/.github/workflows/release.yml@refs/heads/main",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if identity := SignatureIdentity(tt.version); identity != tt.expected {
+				t.Errorf("expected identity: %v, got: %v", tt.expected, identity)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package verman
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	toolVersionsFileName = ".tool-versions"
+	spinManifestFileName = "spin.toml"
+
+	// spinManifestTable and spinManifestKey locate the required Spin version in a Spin manifest:
+	//
+	//	[tool.verman]
+	//	spin_version = "2.7.0"
+	spinManifestTable = "tool.verman"
+	spinManifestKey   = "spin_version"
+)
+
+// VersionSource is a version of Spin requested by a project, along with the file it was requested in
+type VersionSource struct {
+	Version string
+	Path    string
+	// SpinVersionFile is only set when the version came from a ".spin-version" file
+	SpinVersionFile *SpinVersionFile
+}
+
+// FindVersionSource returns the version of Spin requested by the project containing dir, or nil if none is requested.
+// Sources are consulted in order of precedence: ".spin-version", then asdf's ".tool-versions" ("spin x.y.z"), then the
+// "spin_version" field of the "[tool.verman]" table in "spin.toml". Each is searched for in dir and its parents.
+func FindVersionSource(dir string) (*VersionSource, error) {
+	versionFile, err := FindSpinVersionFile(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if versionFile != "" {
+		parsed, err := ParseSpinVersionFile(versionFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if parsed.Version != "" {
+			return &VersionSource{Version: parsed.Version, Path: versionFile, SpinVersionFile: parsed}, nil
+		}
+	}
+
+	for _, source := range []struct {
+		fileName string
+		parse    func(string) (string, error)
+	}{
+		{toolVersionsFileName, parseToolVersions},
+		{spinManifestFileName, parseSpinManifest},
+	} {
+		filePath, err := findFileUpwards(dir, source.fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		if filePath == "" {
+			continue
+		}
+
+		version, err := source.parse(filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		if version != "" {
+			return &VersionSource{Version: version, Path: filePath}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// parseToolVersions returns the Spin version from an asdf ".tool-versions" file. When several versions are listed,
+// asdf treats the rest as fallbacks, so the first is used.
+func parseToolVersions(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "spin" {
+			return fields[1], nil
+		}
+	}
+
+	return "", scanner.Err()
+}
+
+// spinManifest is the part of a Spin manifest that requests a version of Spin
+type spinManifest struct {
+	Tool struct {
+		Verman struct {
+			SpinVersion string `toml:"spin_version"`
+		} `toml:"verman"`
+	} `toml:"tool"`
+}
+
+// parseSpinManifest returns the Spin version from the "[tool.verman]" table of a Spin manifest
+func parseSpinManifest(filePath string) (string, error) {
+	var manifest spinManifest
+	if _, err := toml.DecodeFile(filePath, &manifest); err != nil {
+		return "", fmt.Errorf("unable to read %s.%s from %s: %v", spinManifestTable, spinManifestKey, filePath, err)
+	}
+
+	return manifest.Tool.Verman.SpinVersion, nil
+}
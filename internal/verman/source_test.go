@@ -0,0 +1,105 @@
+package verman
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindVersionSource(t *testing.T) {
+	tests := []struct {
+		name            string
+		files           map[string]string
+		expectedVersion string
+		expectedFile    string
+	}{
+		{
+			name:            ".tool-versions",
+			files:           map[string]string{toolVersionsFileName: "golang 1.22.4\nspin 2.7.0 2.6.0 # fallback\n"},
+			expectedVersion: "2.7.0",
+			expectedFile:    toolVersionsFileName,
+		},
+		{
+			name:            "spin.toml",
+			files:           map[string]string{spinManifestFileName: "spin_manifest_version = 2\n\n[application]\nname = \"app\"\n\n[tool.verman]\nspin_version = \"^2.5\" # any 2.x from 2.5\n"},
+			expectedVersion: "^2.5",
+			expectedFile:    spinManifestFileName,
+		},
+		{
+			name:            ".spin-version takes precedence",
+			files:           map[string]string{spinVersionFileName: "2.5.0\n", toolVersionsFileName: "spin 2.6.0\n", spinManifestFileName: "[tool.verman]\nspin_version = \"2.7.0\"\n"},
+			expectedVersion: "2.5.0",
+			expectedFile:    spinVersionFileName,
+		},
+		{
+			name:            ".tool-versions without spin falls through to spin.toml",
+			files:           map[string]string{toolVersionsFileName: "golang 1.22.4\n", spinManifestFileName: "[tool.verman]\nspin_version = '2.7.0'\n"},
+			expectedVersion: "2.7.0",
+			expectedFile:    spinManifestFileName,
+		},
+		{
+			name:            "spin.toml with a dotted key",
+			files:           map[string]string{spinManifestFileName: "spin_manifest_version = 2\ntool.verman.spin_version = \"2.7.0\"\n\n[application]\nname = \"app\"\n"},
+			expectedVersion: "2.7.0",
+			expectedFile:    spinManifestFileName,
+		},
+		{
+			name:            "spin.toml with an inline table",
+			files:           map[string]string{spinManifestFileName: "[tool]\nverman = { spin_version = \"~2.6\" }\n"},
+			expectedVersion: "~2.6",
+			expectedFile:    spinManifestFileName,
+		},
+		{
+			name:  "spin.toml without a version",
+			files: map[string]string{spinManifestFileName: "spin_manifest_version = 2\n\n[application]\nname = \"app\"\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.Mkdir(filepath.Join(dir, ".git"), 0755); err != nil {
+				t.Fatalf("failed to create .git directory: %v", err)
+			}
+
+			for fileName, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, fileName), []byte(content), 0644); err != nil {
+					t.Fatalf("failed to write %s: %v", fileName, err)
+				}
+			}
+
+			source, err := FindVersionSource(dir)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.expectedVersion == "" {
+				if source != nil {
+					t.Errorf("expected no version source, got: %+v", source)
+				}
+				return
+			}
+
+			if source == nil {
+				t.Fatalf("expected version %v, got no version source", tt.expectedVersion)
+			}
+			if source.Version != tt.expectedVersion {
+				t.Errorf("expected version: %v, got: %v", tt.expectedVersion, source.Version)
+			}
+			if expectedPath := filepath.Join(dir, tt.expectedFile); source.Path != expectedPath {
+				t.Errorf("expected path: %v, got: %v", expectedPath, source.Path)
+			}
+		})
+	}
+}
+
+func TestParseSpinManifestInvalid(t *testing.T) {
+	manifestPath := filepath.Join(t.TempDir(), spinManifestFileName)
+	if err := os.WriteFile(manifestPath, []byte("[tool.verman]\nspin_version = 2\n"), 0644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+
+	if _, err := parseSpinManifest(manifestPath); err == nil {
+		t.Errorf("expected a version that isn't a string to be rejected")
+	}
+}
@@ -3,7 +3,6 @@ package verman
 import (
 	"fmt"
 	"os"
-	"strings"
 )
 
 const (
@@ -15,7 +14,10 @@ func GetDesiredVersionForSet(args []string) (string, error) {
 	if len(args) > 0 {
 		return args[0], nil
 	}
-	rcVersion := getVersionFromSpinVersionFile()
+	rcVersion, err := getVersionFromProject()
+	if err != nil {
+		return "", err
+	}
 
 	// if rc version is empty, return an error
 	if len(rcVersion) == 0 {
@@ -29,7 +31,10 @@ func GetDesiredVersionsForGet(args []string) ([]string, error) {
 	if len(args) > 0 {
 		return args, nil
 	}
-	rcVersion := getVersionFromSpinVersionFile()
+	rcVersion, err := getVersionFromProject()
+	if err != nil {
+		return nil, err
+	}
 
 	// if rc version is empty, return an error
 	if len(rcVersion) == 0 {
@@ -38,16 +43,17 @@ func GetDesiredVersionsForGet(args []string) ([]string, error) {
 	return []string{rcVersion}, nil
 }
 
-func getVersionFromSpinVersionFile() string {
-	_, err := os.Stat(spinVersionFileName)
-	if os.IsNotExist(err) {
-		return ""
+// getVersionFromProject returns the version of Spin requested by the project in the working directory, if any
+func getVersionFromProject() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
 	}
 
-	content, err := os.ReadFile(spinVersionFileName)
-	if err != nil {
-		return ""
+	source, err := FindVersionSource(cwd)
+	if err != nil || source == nil {
+		return "", err
 	}
 
-	return strings.TrimSpace(string(content))
+	return source.Version, nil
 }
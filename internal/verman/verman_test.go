@@ -2,6 +2,7 @@ package verman
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -134,3 +135,63 @@ func equalStringSlices(a, b []string) bool {
 	}
 	return true
 }
+
+func TestFindSpinVersionFile(t *testing.T) {
+	// root/
+	//   .spin-version    (outside of the repository)
+	//   other/dir/
+	//   repo/
+	//     .git/
+	//     apps/api/
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	nested := filepath.Join(repo, "apps", "api")
+	outside := filepath.Join(root, "other", "dir")
+	for _, dir := range []string{filepath.Join(repo, ".git"), nested, outside} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create directories: %v", err)
+		}
+	}
+
+	outsideFile := filepath.Join(root, spinVersionFileName)
+	if err := os.WriteFile(outsideFile, []byte("2.5.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write .spin-version file: %v", err)
+	}
+
+	assertFound := func(dir, expected string) {
+		t.Helper()
+
+		found, err := FindSpinVersionFile(dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if found != expected {
+			t.Errorf("expected file from %s: %q, got: %q", dir, expected, found)
+		}
+	}
+
+	// The search stops at the repository root, so the file above it is ignored from inside the repository
+	assertFound(nested, "")
+	assertFound(repo, "")
+
+	// Outside of a repository, the search walks up to the file
+	assertFound(outside, outsideFile)
+	assertFound(root, outsideFile)
+
+	repoFile := filepath.Join(repo, spinVersionFileName)
+	if err := os.WriteFile(repoFile, []byte("2.7.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write .spin-version file: %v", err)
+	}
+
+	assertFound(nested, repoFile)
+	assertFound(repo, repoFile)
+	assertFound(outside, outsideFile)
+
+	version, err := ReadSpinVersionFile(repoFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version != "2.7.0" {
+		t.Errorf("expected version: 2.7.0, got: %v", version)
+	}
+}
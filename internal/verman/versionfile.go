@@ -0,0 +1,168 @@
+package verman
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SpinVersionFile is the parsed content of a ".spin-version" file. The file either contains just a version, or
+// "key = value" lines declaring the version along with the plugins and templates the project requires:
+//
+//	# Pin Spin for the whole repository
+//	version = 2.7.0
+//	plugins = js2wasm@0.6.1, cloud
+//	templates = http-rust, http-go
+type SpinVersionFile struct {
+	Path      string
+	Version   string
+	Plugins   []Requirement
+	Templates []Requirement
+}
+
+// Requirement is a plugin or template required by a project, optionally pinned to a version ("name@version")
+type Requirement struct {
+	Name    string
+	Version string
+}
+
+func (r Requirement) String() string {
+	if r.Version == "" {
+		return r.Name
+	}
+
+	return r.Name + "@" + r.Version
+}
+
+// FindSpinVersionFile walks up from dir and returns the path of the first ".spin-version" file found, or an empty string if
+// there is none. The search stops at the root of the enclosing git repository, or at the filesystem root outside of one.
+func FindSpinVersionFile(dir string) (string, error) {
+	return findFileUpwards(dir, spinVersionFileName)
+}
+
+// findFileUpwards walks up from dir and returns the path of the first file with the given name, stopping at the root of
+// the enclosing git repository or the filesystem root
+func findFileUpwards(dir, fileName string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		candidate := filepath.Join(dir, fileName)
+
+		info, err := os.Stat(candidate)
+		if err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+
+		// ".git" is a directory in a regular checkout, and a file in worktrees and submodules
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+
+		dir = parent
+	}
+}
+
+// ReadSpinVersionFile returns the version of Spin requested by the ".spin-version" file at the given path
+func ReadSpinVersionFile(filePath string) (string, error) {
+	parsed, err := ParseSpinVersionFile(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	return parsed.Version, nil
+}
+
+// ParseSpinVersionFile reads and parses the ".spin-version" file at the given path
+func ParseSpinVersionFile(filePath string) (*SpinVersionFile, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	parsed := &SpinVersionFile{Path: filePath}
+
+	scanner := bufio.NewScanner(f)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		key, value, isKeyValue := cutKeyValue(line)
+		if !isKeyValue {
+			key, value = "version", line
+		}
+
+		switch key {
+		case "version", "spin":
+			if parsed.Version != "" {
+				return nil, fmt.Errorf("%s:%d: the Spin version is declared more than once", filePath, lineNumber)
+			}
+
+			parsed.Version = value
+		case "plugin", "plugins":
+			parsed.Plugins = append(parsed.Plugins, parseRequirements(value)...)
+		case "template", "templates":
+			parsed.Templates = append(parsed.Templates, parseRequirements(value)...)
+		default:
+			return nil, fmt.Errorf("%s:%d: unknown key %q", filePath, lineNumber, key)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return parsed, nil
+}
+
+// cutKeyValue splits "key = value" and "key: value" lines, returning false for lines that are just a value
+func cutKeyValue(line string) (string, string, bool) {
+	i := strings.IndexAny(line, "=:")
+	if i < 0 {
+		return "", "", false
+	}
+
+	key := strings.ToLower(strings.TrimSpace(line[:i]))
+
+	// Version constraints such as ">=2.5" contain "=" but have no key
+	if key == "" || strings.ContainsAny(key, " <>~^") {
+		return "", "", false
+	}
+
+	return key, strings.Trim(strings.TrimSpace(line[i+1:]), `"'`), true
+}
+
+func parseRequirements(value string) []Requirement {
+	var requirements []Requirement
+
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+		name, version, _ := strings.Cut(item, "@")
+		requirements = append(requirements, Requirement{Name: name, Version: version})
+	}
+
+	return requirements
+}
@@ -0,0 +1,86 @@
+package verman
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSpinVersionFile(t *testing.T) {
+	tests := []struct {
+		name              string
+		content           string
+		expectedVersion   string
+		expectedPlugins   []string
+		expectedTemplates []string
+		expectError       bool
+	}{
+		{
+			name:            "Version only",
+			content:         "2.7.0\n",
+			expectedVersion: "2.7.0",
+		},
+		{
+			name:            "Comments and blank lines",
+			content:         "# Pinned for the whole repository\n\n  v2.7.0  # latest tested\n",
+			expectedVersion: "v2.7.0",
+		},
+		{
+			name:            "Version constraint",
+			content:         ">=2.5 <3\n",
+			expectedVersion: ">=2.5 <3",
+		},
+		{
+			name:              "Key/value form",
+			content:           "version = \"^2.5\"\nplugins = js2wasm@0.6.1, cloud\nplugin: kube\ntemplates = http-rust http-go\n",
+			expectedVersion:   "^2.5",
+			expectedPlugins:   []string{"js2wasm@0.6.1", "cloud", "kube"},
+			expectedTemplates: []string{"http-rust", "http-go"},
+		},
+		{
+			name:        "Unknown key",
+			content:     "color = blue\n",
+			expectError: true,
+		},
+		{
+			name:        "Version declared twice",
+			content:     "2.7.0\nversion = 2.6.0\n",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), spinVersionFileName)
+			if err := os.WriteFile(filePath, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write .spin-version file: %v", err)
+			}
+
+			parsed, err := ParseSpinVersionFile(filePath)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error: %v, got: %v", tt.expectError, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if parsed.Version != tt.expectedVersion {
+				t.Errorf("expected version: %v, got: %v", tt.expectedVersion, parsed.Version)
+			}
+			if !equalStringSlices(requirementStrings(parsed.Plugins), tt.expectedPlugins) {
+				t.Errorf("expected plugins: %v, got: %v", tt.expectedPlugins, parsed.Plugins)
+			}
+			if !equalStringSlices(requirementStrings(parsed.Templates), tt.expectedTemplates) {
+				t.Errorf("expected templates: %v, got: %v", tt.expectedTemplates, parsed.Templates)
+			}
+		})
+	}
+}
+
+func requirementStrings(requirements []Requirement) []string {
+	var result []string
+	for _, requirement := range requirements {
+		result = append(result, requirement.String())
+	}
+	return result
+}